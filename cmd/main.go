@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"k8s.io/klog/v2"
 
 	pkgcmd "github.com/tkashem/rebase/pkg/cmd"
+	"github.com/tkashem/rebase/pkg/git"
 )
 
 func main() {
@@ -16,25 +20,59 @@ func main() {
 
 	klog.InitFlags(nil)
 
+	// the first interrupt cancels the context, the command stops at a
+	// point it can resume from, a second one terminates the process.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		klog.Infof("interrupted, stopping at the next safe point, interrupt again to terminate")
+		signal.Stop(signals)
+		cancel()
+	}()
+
 	root := NewRootCommand()
-	if err := root.Execute(); err != nil {
+	if err := root.ExecuteContext(ctx); err != nil {
 		os.Exit(1)
 	}
 }
 
 func NewRootCommand() *cobra.Command {
+	worktree := &pkgcmd.WorktreeOptions{}
+
 	cmd := &cobra.Command{
 		Use:          "rebase",
 		Short:        "rebase helper",
 		SilenceUsage: true,
+		PersistentPreRunE: func(c *cobra.Command, args []string) error {
+			return worktree.Enter(c)
+		},
 		RunE: func(c *cobra.Command, args []string) error {
 			return nil
 		},
 	}
 
+	cmd.PersistentFlags().DurationVar(&git.NetworkTimeout, "network-timeout", git.NetworkTimeout, "timeout of each call to a remote, ie. the GitHub API")
+	worktree.AddFlags(cmd.PersistentFlags())
+
 	cmd.AddCommand(pkgcmd.NewApplyCommand())
 	cmd.AddCommand(pkgcmd.NewVerifyCommand())
 	cmd.AddCommand(pkgcmd.NewCopyCommand())
+	cmd.AddCommand(pkgcmd.NewMigrateCommand())
+	cmd.AddCommand(pkgcmd.NewForecastCommand())
+	cmd.AddCommand(pkgcmd.NewExplainConflictCommand())
+	cmd.AddCommand(pkgcmd.NewRunRecipeCommand())
+	cmd.AddCommand(pkgcmd.NewPinCommand())
+	cmd.AddCommand(pkgcmd.NewUnpinCommand())
+	cmd.AddCommand(pkgcmd.NewCheckVendorCommand())
+	cmd.AddCommand(pkgcmd.NewBumpVersionCommand())
+	cmd.AddCommand(pkgcmd.NewResolveTargetCommand())
+	cmd.AddCommand(pkgcmd.NewAdvanceCommand())
+	cmd.AddCommand(pkgcmd.NewRefreshCommand())
+	cmd.AddCommand(pkgcmd.NewRollbackCommand())
+	cmd.AddCommand(pkgcmd.NewBisectCommand())
 
 	return cmd
 }
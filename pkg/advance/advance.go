@@ -0,0 +1,249 @@
+package advance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"k8s.io/klog/v2"
+)
+
+func New(ctx context.Context, from, to *target.Target, source, branch string) (*cmd, error) {
+	gitAPI, err := git.OpenWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := gitAPI.CheckRemotes(ctx); err != nil {
+		return nil, fmt.Errorf("git repo not setup properly: %v", err)
+	}
+
+	if len(branch) == 0 {
+		branch = fmt.Sprintf("rebase-%s", to.String())
+	}
+	return &cmd{
+		git:        gitAPI,
+		from:       from,
+		to:         to,
+		source:     source,
+		branch:     branch,
+		fromMarker: from.Marker(),
+		toMarker:   to.Marker(),
+	}, nil
+}
+
+type cmd struct {
+	git                  git.Git
+	from, to             *target.Target
+	source, branch       string
+	fromMarker, toMarker string
+}
+
+func (c *cmd) Run(ctx context.Context) error {
+	current, err := c.git.CurrentBranch(ctx)
+	if err != nil {
+		return err
+	}
+	if len(c.source) == 0 {
+		if current == c.branch {
+			return fmt.Errorf("already on %s, specify --source to resume advancing from the previous rebase branch", c.branch)
+		}
+		c.source = current
+	}
+
+	klog.InfoS("advance in progress", "from", c.from.String(), "to", c.to.String(), "source", c.source, "branch", c.branch)
+	sourceMarker, err := c.git.FindRebaseMarkerCommit(ctx, c.source, c.fromMarker)
+	if err != nil {
+		return fmt.Errorf("rebase marker not found in %s - %w", c.source, err)
+	}
+	if sourceMarker.NumParents() != 2 {
+		return fmt.Errorf("rebase marker %s of %s is not a merge commit", sourceMarker.Hash.String(), c.source)
+	}
+
+	if current != c.branch {
+		if err := c.setup(ctx, sourceMarker); err != nil {
+			return err
+		}
+	}
+	marker, err := c.git.FindRebaseMarkerCommit(ctx, "", c.toMarker)
+	if err != nil {
+		return fmt.Errorf("rebase marker not found in %s - %w", c.branch, err)
+	}
+
+	sourceCommits, err := c.carries(ctx, c.source, sourceMarker.Hash.String())
+	if err != nil {
+		return err
+	}
+	klog.InfoS("copying commits", "count", len(sourceCommits))
+
+	// a commit picked before the last run was interrupted by a conflict
+	// still has the metadata of the previous target
+	if err := c.rewriteHead(ctx, marker); err != nil {
+		return err
+	}
+	for _, commit := range sourceCommits {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted before %s, run advance with --source=%s again to resume", commit.Hash.String(), c.source)
+		}
+		copied, err := c.copied(ctx, commit, marker.Hash.String())
+		if err != nil {
+			return err
+		}
+		if copied {
+			klog.V(2).Infof("status=copied do=noop - %s", git.Subject(commit.Message))
+			continue
+		}
+
+		klog.Infof("status=not-copied do=cherry-pick - %s %s", commit.Hash.String()[:11], git.Subject(commit.Message))
+		if err := c.git.CherryPick(ctx, commit.Hash.String()); err != nil {
+			return fmt.Errorf("failed to copy %s, resolve the conflict, commit, and run advance with --source=%s again - %w",
+				commit.Hash.String(), c.source, err)
+		}
+		if err := c.rewriteHead(ctx, marker); err != nil {
+			return err
+		}
+	}
+
+	return c.verify(ctx, sourceCommits, marker.Hash.String())
+}
+
+// setup creates the new rebase branch at the new tag, and records the
+// same openshift commit the previous rebase branch merged in the marker.
+func (c *cmd) setup(ctx context.Context, sourceMarker *gitv5object.Commit) error {
+	exists, err := c.git.BranchExists(ctx, c.branch)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("branch %s already exists, check it out and run advance with --source=%s to resume", c.branch, c.source)
+	}
+
+	openshift := sourceMarker.ParentHashes[1].String()
+	if err := c.git.CreateBranch(ctx, c.branch, c.to.String()); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Merge remote-tracking branch 'openshift/master' into %s %s", c.branch, c.toMarker)
+	if err := c.git.MergeOurs(ctx, openshift, message); err != nil {
+		return err
+	}
+	klog.InfoS("created rebase branch", "branch", c.branch, "tag", c.to.String(), "openshift", openshift)
+	return nil
+}
+
+// carries returns the commits on top of the marker, oldest first.
+func (c *cmd) carries(ctx context.Context, from, marker string) ([]*gitv5object.Commit, error) {
+	commits, err := c.git.Log(ctx, from, marker)
+	if err != nil {
+		return nil, fmt.Errorf("git log failed with error: %w", err)
+	}
+	// the last commit is the marker commit, we can exclude it
+	if len(commits) > 0 {
+		commits = commits[0 : len(commits)-1]
+	}
+
+	reversed := make([]*gitv5object.Commit, 0, len(commits))
+	for i := len(commits) - 1; i >= 0; i-- {
+		reversed = append(reversed, commits[i])
+	}
+	return reversed, nil
+}
+
+func (c *cmd) copied(ctx context.Context, source *gitv5object.Commit, marker string) (bool, error) {
+	commits, err := c.git.Log(ctx, "", marker)
+	if err != nil {
+		return false, fmt.Errorf("git log failed with error: %w", err)
+	}
+
+	want := rewrite(source.Message, c.from, c.to)
+	for _, commit := range commits {
+		if strings.TrimSpace(commit.Message) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rewriteHead moves the rebase metadata of the commit at HEAD to the
+// new target, so the lineage of each carry stays intact.
+func (c *cmd) rewriteHead(ctx context.Context, marker *gitv5object.Commit) error {
+	head, err := c.git.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if c.from.String() == c.to.String() || head.Hash == marker.Hash || !strings.Contains(head.Message, prefix(c.from)) {
+		return nil
+	}
+
+	return c.git.AmendCommitMessage(ctx, func(current string) []string {
+		return []string{rewrite(current, c.from, c.to)}
+	})
+}
+
+// verify checks that the new rebase branch has the same set of
+// commits as the previous one.
+func (c *cmd) verify(ctx context.Context, sourceCommits []*gitv5object.Commit, marker string) error {
+	copies, err := c.carries(ctx, "", marker)
+	if err != nil {
+		return err
+	}
+
+	want := make([]string, 0, len(sourceCommits))
+	for _, commit := range sourceCommits {
+		want = append(want, rewrite(commit.Message, c.from, c.to))
+	}
+	got := make([]string, 0, len(copies))
+	for _, commit := range copies {
+		got = append(got, strings.TrimSpace(commit.Message))
+	}
+
+	missing, extra := diff(want, got)
+	for _, msg := range missing {
+		klog.Infof("verify: missing in %s - %s", c.branch, git.Subject(msg))
+	}
+	for _, msg := range extra {
+		klog.Infof("verify: not in %s - %s", c.source, git.Subject(msg))
+	}
+	klog.Infof("stats: source(%d), copied(%d), missing(%d), extra(%d)", len(want), len(got), len(missing), len(extra))
+	if len(missing) > 0 || len(extra) > 0 {
+		return fmt.Errorf("the carry set of %s does not match %s", c.branch, c.source)
+	}
+
+	klog.InfoS("advance has completed", "branch", c.branch, "tag", c.to.String())
+	return nil
+}
+
+// prefix returns the prefix every rebase metadata key of the target has.
+func prefix(t *target.Target) string { return t.Metadata("") }
+
+// rewrite replaces the rebase metadata of the given target in the
+// message with the metadata of the new target.
+func rewrite(msg string, from, to *target.Target) string {
+	return strings.TrimSpace(strings.ReplaceAll(msg, prefix(from), prefix(to)))
+}
+
+// diff returns the messages in want but not in got, and the ones in got
+// but not in want, duplicates are counted.
+func diff(want, got []string) ([]string, []string) {
+	counts := map[string]int{}
+	for _, msg := range got {
+		counts[msg]++
+	}
+	missing := make([]string, 0)
+	for _, msg := range want {
+		if counts[msg] > 0 {
+			counts[msg]--
+			continue
+		}
+		missing = append(missing, msg)
+	}
+	extra := make([]string, 0)
+	for _, msg := range got {
+		if counts[msg] > 0 {
+			counts[msg]--
+			extra = append(extra, msg)
+		}
+	}
+	return missing, extra
+}
@@ -0,0 +1,31 @@
+package advance
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+func TestRewrite(t *testing.T) {
+	msg := "UPSTREAM: <carry>: add c\n\nopenshift-rebase(v1.24.0-rc.0):source=657e5b0959f\n"
+	want := "UPSTREAM: <carry>: add c\n\nopenshift-rebase(v1.24.0):source=657e5b0959f"
+	from, _ := target.Parse("v1.24.0-rc.0")
+	to, _ := target.Parse("v1.24.0")
+	if got := rewrite(msg, from, to); got != want {
+		t.Errorf("Expected: %q, but got: %q", want, got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	want := []string{"a", "b", "b", "c"}
+	got := []string{"a", "b", "d"}
+
+	missing, extra := diff(want, got)
+	if !reflect.DeepEqual([]string{"b", "c"}, missing) {
+		t.Errorf("Expected missing: [b c], but got: %v", missing)
+	}
+	if !reflect.DeepEqual([]string{"d"}, extra) {
+		t.Errorf("Expected extra: [d], but got: %v", extra)
+	}
+}
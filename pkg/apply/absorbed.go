@@ -0,0 +1,212 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// the upstream commits that touched the files of the carry are
+	// compared with it, the most recent ones first
+	candidates = 50
+	// the share of the changed lines of the carry a candidate must
+	// have to be suggested
+	threshold = 0.5
+)
+
+// absorbed is a carry whose pick is empty, its change is already in
+// the target, ie. it was fixed upstream under a different PR.
+type absorbed struct {
+	commit *carry.CommitSummary
+	// likely is the commit that most likely absorbed the carry, if any
+	likely *git.LogEntry
+	score  float64
+}
+
+// emptyPick returns true if the pick of the carry has nothing to
+// commit, either the cherry-pick stopped as the result is empty, or it
+// committed an empty commit on top of head.
+func (s *processor) emptyPick(ctx context.Context, r *carry.CommitSummary, head string, picking bool) (bool, error) {
+	if picking {
+		ws, err := s.git.Workspace(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to inspect the working tree - %w", err)
+		}
+		if len(ws.Operations) != 1 || ws.Operations[0] != git.CherryPickInProgress || len(ws.Changed) > 0 {
+			return false, nil
+		}
+	} else {
+		current, err := s.git.Head(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to get HEAD: %w", err)
+		}
+		if current.Hash.String() == head {
+			return false, nil
+		}
+		if isEmpty, err := empty(current); err != nil || !isEmpty {
+			return false, err
+		}
+	}
+
+	// a carry that is empty itself is carried as is
+	files, err := s.git.ChangedFiles(ctx, r.SHA)
+	if err != nil {
+		return false, fmt.Errorf("failed to list files changed by %s - %w", r.String(), err)
+	}
+	return len(files) > 0, nil
+}
+
+// absorb records the carry as absorbed upstream, it returns true if the
+// carry is not committed. With keepEmpty, it is committed as an empty
+// commit so it carries the rebase metadata like any other carry.
+func (s *processor) absorb(ctx context.Context, r *carry.CommitSummary, head string, picking bool) (bool, error) {
+	entry := absorbed{commit: r}
+	entry.likely, entry.score = s.likelyUpstream(ctx, r, head)
+	s.absorbed = append(s.absorbed, entry)
+
+	if s.keepEmpty {
+		klog.Infof("status=absorbed-upstream do=commit-empty - %s", r.String())
+		if picking {
+			if err := s.git.CommitEmptyCherryPick(ctx); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	klog.Infof("status=absorbed-upstream do=skip - %s", r.String())
+	if picking {
+		if err := s.git.AbortCherryPick(ctx); err != nil {
+			return false, err
+		}
+	} else if err := s.git.ResetHard(ctx, head); err != nil {
+		return false, err
+	}
+	return true, s.guard.Finish()
+}
+
+// likelyUpstream returns the commit in HEAD, and not in the carry, that
+// touched the files of the carry with the patch most similar to it. It
+// is a suggestion only, a failure is logged, and nothing is returned.
+func (s *processor) likelyUpstream(ctx context.Context, r *carry.CommitSummary, head string) (*git.LogEntry, float64) {
+	files, err := s.git.ChangedFiles(ctx, r.SHA)
+	if err != nil {
+		klog.ErrorS(err, "failed to list files changed by the carry", "carry", r.String())
+		return nil, 0
+	}
+	patch, err := s.git.Patch(ctx, r.SHA, files)
+	if err != nil {
+		klog.ErrorS(err, "failed to read the patch of the carry", "carry", r.String())
+		return nil, 0
+	}
+	entries, err := s.git.LogPaths(ctx, fmt.Sprintf("%s..%s", r.SHA, head), files, candidates)
+	if err != nil {
+		klog.ErrorS(err, "failed to list the commits that touched the files of the carry", "carry", r.String())
+		return nil, 0
+	}
+
+	var likely *git.LogEntry
+	best := 0.0
+	for i := range entries {
+		other, err := s.git.Patch(ctx, entries[i].SHA, files)
+		if err != nil {
+			klog.ErrorS(err, "failed to read the patch of a candidate", "sha", entries[i].SHA)
+			continue
+		}
+		if score := similarity(patch, other); score > best {
+			likely, best = &entries[i], score
+		}
+	}
+	if best < threshold {
+		return nil, 0
+	}
+	return likely, best
+}
+
+func (s *processor) printAbsorbed() {
+	if len(s.absorbed) == 0 {
+		return
+	}
+
+	b := &strings.Builder{}
+	for i, entry := range s.absorbed {
+		fmt.Fprintf(b, "%d. %s\n", i+1, entry.commit.MessageWithPrefix)
+		fmt.Fprintf(b, "   commit: %s %s\n", entry.commit.ShortSHA(), entry.commit.OpenShiftCommit)
+		if entry.likely == nil {
+			fmt.Fprintf(b, "   likely upstream: not found\n")
+		} else {
+			fmt.Fprintf(b, "   likely upstream: %s %s (similarity %.0f%%)\n", entry.likely.SHA[:11], entry.likely.Subject, entry.score*100)
+		}
+	}
+	fmt.Fprintf(b, "\nsuggested overrides:\n%s", suggest(s.absorbed))
+
+	klog.Infof("absorbed upstream: carries(%d)\n%s", len(s.absorbed), b.String())
+}
+
+// suggest returns the drop overrides for the absorbed carries, in the
+// format of the override file.
+func suggest(entries []absorbed) string {
+	b := &strings.Builder{}
+	for _, entry := range entries {
+		fmt.Fprintf(b, "# %s\n", entry.commit.MessageWithPrefix)
+		if entry.likely != nil {
+			fmt.Fprintf(b, "# absorbed upstream by %s %s\n", entry.likely.SHA[:11], entry.likely.Subject)
+			fmt.Fprintf(b, "# https://github.com/kubernetes/kubernetes/commit/%s\n", entry.likely.SHA)
+		} else {
+			fmt.Fprintf(b, "# absorbed upstream, the pick is empty\n")
+		}
+		fmt.Fprintf(b, "- sha: %s\n  do: drop\n\n", entry.commit.ShortSHA())
+	}
+	return b.String()
+}
+
+// similarity returns the share of the lines the carry adds or removes
+// that the other patch adds or removes as well, whitespace aside.
+func similarity(patch, other string) float64 {
+	changed := func(patch string) map[string]int {
+		lines := map[string]int{}
+		for _, line := range strings.Split(patch, "\n") {
+			if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+				continue
+			}
+			if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+				if trimmed := strings.TrimSpace(line[1:]); len(trimmed) > 0 {
+					lines[line[:1]+trimmed]++
+				}
+			}
+		}
+		return lines
+	}
+
+	mine, theirs := changed(patch), changed(other)
+	total, common := 0, 0
+	for line, count := range mine {
+		total += count
+		if count > theirs[line] {
+			count = theirs[line]
+		}
+		common += count
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(common) / float64(total)
+}
+
+// empty returns true if the commit has the same tree as its parent.
+func empty(commit *gitv5object.Commit) (bool, error) {
+	if commit.NumParents() == 0 {
+		return false, nil
+	}
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the parent of %s - %w", commit.Hash.String(), err)
+	}
+	return commit.TreeHash == parent.TreeHash, nil
+}
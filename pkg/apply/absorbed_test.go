@@ -0,0 +1,67 @@
+package apply
+
+import (
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	carry := `diff --git a/pkg/kubelet/kubelet.go b/pkg/kubelet/kubelet.go
+--- a/pkg/kubelet/kubelet.go
++++ b/pkg/kubelet/kubelet.go
+@@ -10,7 +10,7 @@ func run() {
+ 	ctx := context.TODO()
+-	if err := start(ctx); err != nil {
++	if err := start(ctx, opts); err != nil {
+ 		return err
+ 	}
++	klog.Info("started")
+`
+
+	tests := []struct {
+		name     string
+		other    string
+		expected float64
+	}{
+		{
+			name:     "the same patch",
+			other:    carry,
+			expected: 1,
+		},
+		{
+			name: "the same change, indented differently, with more changes",
+			other: `--- a/pkg/kubelet/kubelet.go
++++ b/pkg/kubelet/kubelet.go
+-  if err := start(ctx); err != nil {
++  if err := start(ctx, opts); err != nil {
++  klog.Info("started")
++  klog.Info("running")
+`,
+			expected: 1,
+		},
+		{
+			name: "a part of the change",
+			other: `-	if err := start(ctx); err != nil {
++	if err := start(ctx, opts, extra); err != nil {
+`,
+			expected: 1.0 / 3,
+		},
+		{
+			name:     "an added line is not a removed line",
+			other:    `+	if err := start(ctx); err != nil {`,
+			expected: 0,
+		},
+		{
+			name:     "nothing in common",
+			other:    "",
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := similarity(carry, test.other); got != test.expected {
+				t.Errorf("Expected similarity: %v, but got: %v", test.expected, got)
+			}
+		})
+	}
+}
@@ -1,33 +1,40 @@
 package apply
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
-	"runtime"
 
+	"github.com/tkashem/rebase/pkg/backup"
 	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/command"
 	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"github.com/tkashem/rebase/pkg/workspace"
 	"k8s.io/klog/v2"
 )
 
-type DoFunc func(*carry.CommitSummary) error
+type DoFunc func(context.Context, *carry.CommitSummary) error
 
 type Processor interface {
-	Init() error
+	Init(context.Context) error
 	Done() error
-	Step(*carry.CommitSummary) (DoFunc, error)
+	Step(context.Context, *carry.CommitSummary) (DoFunc, error)
 }
 
-func New(reader carry.CommitReader, override carry.Prompt, target string, cherryPickFromSHA string) (*cmd, error) {
-	accessor, err := git.Initialize(target)
+func New(ctx context.Context, reader carry.CommitReader, override carry.Prompt, t *target.Target, cherryPickFromSHA string, base string, keepGoing bool, mode workspace.Mode, checkpointEvery int, hooks Hooks, keepEmpty bool) (*cmd, error) {
+	accessor, err := git.Initialize(ctx, t)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize git - %w", err)
 	}
+	guard, err := workspace.New(ctx, accessor.Git, "apply", t.String())
+	if err != nil {
+		return nil, err
+	}
 
 	var cherryStopAtSHA string
 	if len(cherryPickFromSHA) > 0 {
 		klog.InfoS("looking for rebase marker for cherry-pick branch", "pattern", accessor.Marker)
-		cherryPickStopAt, err := accessor.Git.FindRebaseMarkerCommit(cherryPickFromSHA, accessor.Marker)
+		cherryPickStopAt, err := accessor.Git.FindRebaseMarkerCommit(ctx, cherryPickFromSHA, accessor.Marker)
 		if err != nil {
 			return nil, err
 		}
@@ -36,49 +43,94 @@ func New(reader carry.CommitReader, override carry.Prompt, target string, cherry
 	}
 
 	return &cmd{
-		reader: reader,
+		reader:   reader,
+		git:      accessor.Git,
+		guard:    guard,
+		mode:     mode,
+		recorder: backup.NewRecorder(accessor.Git, "apply", t.String(), checkpointEvery),
 		processor: &processor{
 			override:  override,
 			git:       accessor.Git,
 			github:    accessor.GitHub,
-			target:    target,
+			runner:    &command.Runner{Executor: command.NewShellExecutor("")},
+			target:    t.String(),
 			marker:    accessor.Marker,
-			metadata:  fmt.Sprintf("openshift-rebase(%s):source", target),
+			metadata:  accessor.MetadataSource,
 			stopAtSHA: accessor.StopAtCommitSHA,
 
 			cherryPickFromSHA: cherryPickFromSHA,
 			cherryStopAtSHA:   cherryStopAtSHA,
+			base:              base,
+
+			keepGoingOnConflict: keepGoing,
+
+			guard: guard,
+			mode:  mode,
+
+			hooks:        hooks,
+			hookExecutor: command.NewShellExecutor(""),
+			hookMark:     t.Metadata("hook-failed"),
+
+			keepEmpty: keepEmpty,
 		},
 	}, nil
 }
 
 type cmd struct {
 	reader    carry.CommitReader
+	git       git.Git
 	processor Processor
+	guard     *workspace.Guard
+	mode      workspace.Mode
+	recorder  *backup.Recorder
 }
 
-func (c *cmd) Run() error {
-	commits, err := c.reader.Read()
+func (c *cmd) Run(ctx context.Context) error {
+	if err := c.guard.Lock(); err != nil {
+		return err
+	}
+	defer c.guard.Unlock()
+	if err := c.recorder.Backup(ctx); err != nil {
+		return err
+	}
+	if c.mode == workspace.Abort {
+		return c.guard.Abort(ctx)
+	}
+
+	commits, err := c.reader.Read(ctx)
 	if err != nil {
 		return err
 	}
 
-	if err := c.processor.Init(); err != nil {
+	if err := c.processor.Init(ctx); err != nil {
 		return fmt.Errorf("initialization failed with: %w", err)
 	}
 
 	// the logs are in right order, the oldest commit should be applied first
 	for i, _ := range commits {
 		commit := commits[i]
-		doFn, err := c.processor.Step(commit)
+		if ctx.Err() != nil {
+			return c.interrupted(i, commits)
+		}
+		doFn, err := c.processor.Step(ctx, commit)
 		if err != nil {
+			if ctx.Err() != nil {
+				return c.interrupted(i, commits)
+			}
+			return err
+		}
+		if err := doFn(ctx, commit); err != nil {
+			if ctx.Err() != nil {
+				return c.interrupted(i, commits)
+			}
 			return err
 		}
-		if err := doFn(commit); err != nil {
+		if err := c.recorder.Applied(ctx); err != nil {
 			return err
 		}
 	}
 
+	carry.PrintReverts(c.reader)
 	if err := c.processor.Done(); err != nil {
 		return fmt.Errorf("cleaup failed with: %w", err)
 	}
@@ -86,26 +138,35 @@ func (c *cmd) Run() error {
 	return nil
 }
 
-// open a browser window with the openshift commit
-// 	if errors.Is(err, &CherryPickError{}) {
-//		openBrowser(commit.OpenShiftCommit)
-//	}
-// TODO: the browser window does not open, maybe the parent go routine
-//  can't quit immediately?
-func openBrowser(url string) {
-	var err error
-
-	switch runtime.GOOS {
-	case "linux":
-		err = exec.Command("xdg-open", url).Start()
-	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
-	case "darwin":
-		err = exec.Command("open", url).Start()
-	default:
-		err = fmt.Errorf("unsupported platform")
+// interrupted reports where the run stopped, the carries before the
+// i-th are applied, a rerun skips them as they are found in the branch.
+func (c *cmd) interrupted(i int, commits []*carry.CommitSummary) error {
+	// git is never killed half-way, the pick of the i-th carry may have
+	// stopped on a conflict, it is rolled back so a rerun picks it again
+	ctx := context.Background()
+	if ws, err := c.git.Workspace(ctx); err == nil && picking(ws) {
+		if err := c.guard.Abort(ctx); err != nil {
+			klog.ErrorS(err, "failed to roll back the pick in progress", "carry", commits[i].String())
+			klog.Infof("resolve the conflict, and run the same command with --continue, or run it with --abort")
+			return fmt.Errorf("interrupted after %d of %d carries, the pick of %s is in progress", i, len(commits), commits[i].String())
+		}
 	}
-	if err != nil {
-		klog.ErrorS(err, "failed to open a browser window")
+
+	head := "unknown"
+	if commit, err := c.git.Head(ctx); err == nil {
+		head = commit.Hash.String()
+	}
+	klog.InfoS("interrupted, stopped at a safe point", "applied", fmt.Sprintf("%d/%d", i, len(commits)),
+		"next", commits[i].String(), "head", head)
+	klog.Infof("run the same command again to resume from %s", commits[i].String())
+	return fmt.Errorf("interrupted after %d of %d carries", i, len(commits))
+}
+
+func picking(ws *git.Workspace) bool {
+	for _, operation := range ws.Operations {
+		if operation == git.CherryPickInProgress {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,181 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/command"
+	"k8s.io/klog/v2"
+)
+
+// HookPolicy tells apply what to do with a carry when a hook fails.
+type HookPolicy string
+
+const (
+	// HookStop stops apply, the carry stays committed so it can be fixed
+	HookStop HookPolicy = "stop"
+	// HookMark records the failed hooks in the commit message of the carry
+	HookMark HookPolicy = "mark"
+	// HookWarn only logs the failure
+	HookWarn HookPolicy = "warn"
+)
+
+// the staging modules of kubernetes are vendored by the main module
+const staging = "staging/src/"
+
+// PackagesPlaceholder is replaced in a hook with the Go packages the
+// carry touched, ie. 'go vet {packages}'.
+const PackagesPlaceholder = "{packages}"
+
+// Hooks are the shell commands run after each carry is picked.
+type Hooks struct {
+	Commands []string
+	Policy   HookPolicy
+}
+
+func (h Hooks) Validate() error {
+	switch h.Policy {
+	case HookStop, HookMark, HookWarn:
+		return nil
+	}
+	return fmt.Errorf("hook policy must be one of stop, mark or warn")
+}
+
+// validated is the outcome of the hooks of a picked carry.
+type validated struct {
+	commit *carry.CommitSummary
+	head   string
+	failed []string
+}
+
+// validate runs the hooks against the carry that was just committed at
+// HEAD, a hook with the packages placeholder is skipped if the carry
+// touched no Go package.
+func (s *processor) validate(ctx context.Context, r *carry.CommitSummary) error {
+	if len(s.hooks.Commands) == 0 {
+		return nil
+	}
+	head, err := s.git.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	files, err := s.git.ChangedFiles(ctx, head.Hash.String())
+	if err != nil {
+		return fmt.Errorf("failed to list files changed by %s - %w", r.String(), err)
+	}
+	pkgs := packages(files, func(dir string) bool {
+		_, err := os.Stat(dir)
+		return err == nil
+	})
+
+	result := validated{commit: r, head: head.Hash.String()}
+	runner := &command.Runner{Executor: s.hookExecutor}
+	for _, hook := range s.hooks.Commands {
+		if strings.Contains(hook, PackagesPlaceholder) && len(pkgs) == 0 {
+			klog.V(2).Infof("status=no-go-packages do=skip hook=%q - %s", hook, r.String())
+			continue
+		}
+		expanded := strings.ReplaceAll(hook, PackagesPlaceholder, strings.Join(pkgs, " "))
+		if err := runner.Run(ctx, []string{expanded}); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("hook %q of %s was interrupted - %w", hook, r.String(), ctx.Err())
+			}
+			klog.Infof("status=hook-failed do=%s hook=%q - %s", s.hooks.Policy, hook, r.String())
+			result.failed = append(result.failed, hook)
+			continue
+		}
+		klog.Infof("status=hook-passed hook=%q - %s", hook, r.String())
+	}
+	s.validated = append(s.validated, result)
+	if len(result.failed) == 0 {
+		return nil
+	}
+
+	switch s.hooks.Policy {
+	case HookStop:
+		s.printValidated()
+		return fmt.Errorf("hooks %q failed for %s committed as %s, fix the carry and run apply again",
+			result.failed, r.String(), result.head)
+	case HookMark:
+		if err := s.git.AmendCommitMessage(ctx, func(current string) []string {
+			message := strings.TrimRight(current, "\n")
+			for _, hook := range result.failed {
+				message = fmt.Sprintf("%s\n%s=%s", message, s.hookMark, hook)
+			}
+			return []string{message}
+		}); err != nil {
+			return fmt.Errorf("failed to mark %s with the failed hooks - %w", r.String(), err)
+		}
+	}
+	return nil
+}
+
+func (s *processor) printValidated() {
+	if len(s.hooks.Commands) == 0 {
+		return
+	}
+
+	b := &strings.Builder{}
+	failed := 0
+	for _, result := range s.validated {
+		if len(result.failed) == 0 {
+			continue
+		}
+		failed++
+		fmt.Fprintf(b, "%d. %s\n", failed, result.commit.MessageWithPrefix)
+		fmt.Fprintf(b, "   commit: %s %s\n", result.commit.ShortSHA(), result.commit.OpenShiftCommit)
+		fmt.Fprintf(b, "   picked: %s\n", result.head)
+		for _, hook := range result.failed {
+			fmt.Fprintf(b, "   failed: %s\n", hook)
+		}
+	}
+
+	klog.Infof("hook results: carries(%d), failed(%d)\n%s", len(s.validated), failed, b.String())
+}
+
+// packages returns the Go packages, relative to the root of the
+// repository, of the given files. A package of a staging module is
+// its import path, ie. staging/src/k8s.io/apiserver/pkg/server is
+// k8s.io/apiserver/pkg/server, as it is not in the main module. A
+// vendored package, testdata, and a package that no longer exists
+// are left out.
+func packages(files []string, exists func(dir string) bool) []string {
+	seen := map[string]bool{}
+	pkgs := make([]string, 0)
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		dir := path.Dir(file)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		excluded := false
+		for _, element := range strings.Split(dir, "/") {
+			if element == "vendor" || element == "testdata" {
+				excluded = true
+				break
+			}
+		}
+		if excluded || !exists(dir) {
+			continue
+		}
+		switch {
+		case dir == ".":
+			pkgs = append(pkgs, dir)
+		case strings.HasPrefix(dir, staging):
+			pkgs = append(pkgs, strings.TrimPrefix(dir, staging))
+		default:
+			pkgs = append(pkgs, "./"+dir)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
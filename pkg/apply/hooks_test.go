@@ -0,0 +1,68 @@
+package apply
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackages(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		removed  map[string]bool
+		expected []string
+	}{
+		{
+			name:     "no go file",
+			files:    []string{"README.md", "hack/update-codegen.sh"},
+			expected: []string{},
+		},
+		{
+			name: "packages are unique, and sorted",
+			files: []string{
+				"pkg/kubelet/kubelet.go",
+				"cmd/kube-apiserver/app/server.go",
+				"pkg/kubelet/kubelet_test.go",
+				"pkg/kubelet/OWNERS",
+			},
+			expected: []string{"./cmd/kube-apiserver/app", "./pkg/kubelet"},
+		},
+		{
+			name:     "the root of the repository",
+			files:    []string{"doc.go"},
+			expected: []string{"."},
+		},
+		{
+			name: "vendor and testdata are left out",
+			files: []string{
+				"vendor/github.com/google/cadvisor/fs/fs.go",
+				"staging/src/k8s.io/apiserver/pkg/server/testdata/fixture.go",
+				"staging/src/k8s.io/apiserver/pkg/server/config.go",
+			},
+			expected: []string{"k8s.io/apiserver/pkg/server"},
+		},
+		{
+			name: "a package of a staging module is its import path",
+			files: []string{
+				"staging/src/k8s.io/client-go/rest/request.go",
+				"pkg/kubelet/kubelet.go",
+			},
+			expected: []string{"./pkg/kubelet", "k8s.io/client-go/rest"},
+		},
+		{
+			name:     "a package the carry removed is left out",
+			files:    []string{"pkg/removed/removed.go", "pkg/kept/kept.go"},
+			removed:  map[string]bool{"pkg/removed": true},
+			expected: []string{"./pkg/kept"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := packages(test.files, func(dir string) bool { return !test.removed[dir] })
+			if !reflect.DeepEqual(test.expected, got) {
+				t.Errorf("Expected packages: %v, but got: %v", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+	"k8s.io/klog/v2"
+)
+
+// conflicted is a carry commit that was skipped in keep-going mode,
+// along with the files that conflict.
+type conflicted struct {
+	commit    *carry.CommitSummary
+	class     ConflictClass
+	conflicts []git.Conflict
+}
+
+// keepGoing wraps the given DoFunc, if the carry fails to cherry-pick
+// due to a conflict, the pick is aborted, and the conflict is recorded
+// in the inventory so apply can continue with the next carry.
+func (s *processor) keepGoing(do DoFunc) DoFunc {
+	return func(ctx context.Context, r *carry.CommitSummary) error {
+		head, err := s.git.Head(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get HEAD: %w", err)
+		}
+
+		err = do(ctx, r)
+		var cherryPickErr *CherryPickError
+		if err == nil || !errors.As(err, &cherryPickErr) {
+			return err
+		}
+
+		conflicts, conflictErr := s.git.Conflicts(ctx)
+		if conflictErr != nil {
+			return fmt.Errorf("failed to inspect conflicts - %v: %w", conflictErr, err)
+		}
+		if abortErr := s.git.AbortCherryPick(ctx); abortErr != nil {
+			return fmt.Errorf("failed to abort cherry-pick - %v: %w", abortErr, err)
+		}
+		if finishErr := s.guard.Finish(); finishErr != nil {
+			return fmt.Errorf("%v: %w", finishErr, err)
+		}
+
+		// a unit may have been half-applied, we don't leave it behind
+		current, headErr := s.git.Head(ctx)
+		if headErr != nil {
+			return fmt.Errorf("failed to get HEAD: %w", headErr)
+		}
+		if current.Hash != head.Hash {
+			if resetErr := s.git.ResetHard(ctx, head.Hash.String()); resetErr != nil {
+				return fmt.Errorf("failed to roll back %s - %v: %w", r.String(), resetErr, err)
+			}
+		}
+
+		klog.Infof("status=conflict do=skip(keep-going) files=%d - %s", len(conflicts), r.String())
+		s.inventory = append(s.inventory, conflicted{commit: r, class: cherryPickErr.Class(), conflicts: conflicts})
+		return nil
+	}
+}
+
+func (s *processor) printInventory() {
+	if len(s.inventory) == 0 {
+		klog.Infof("conflict inventory: no conflicts")
+		return
+	}
+
+	b := &strings.Builder{}
+	files := 0
+	for i, entry := range s.inventory {
+		commits := []*carry.CommitSummary{entry.commit}
+		if entry.commit.Unit != nil {
+			commits = entry.commit.Unit.Commits
+		}
+		fmt.Fprintf(b, "%d. [%s] %s\n", i+1, entry.class, entry.commit.MessageWithPrefix)
+		for _, commit := range commits {
+			fmt.Fprintf(b, "   commit: %s %s\n", commit.ShortSHA(), commit.OpenShiftCommit)
+		}
+		if len(entry.commit.UpstreamPR) > 0 {
+			fmt.Fprintf(b, "   upstream: %s\n", entry.commit.UpstreamPR)
+		}
+		for _, conflict := range entry.conflicts {
+			files++
+			if len(conflict.Hunks) == 0 {
+				fmt.Fprintf(b, "   %s: deleted on one side\n", conflict.Path)
+				continue
+			}
+			for _, hunk := range conflict.Hunks {
+				fmt.Fprintf(b, "   %s: %s\n", conflict.Path, hunk.String())
+			}
+		}
+	}
+
+	klog.Infof("conflict inventory: carries(%d), files(%d)\n%s", len(s.inventory), files, b.String())
+}
@@ -2,29 +2,57 @@ package apply
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/command"
 	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/workspace"
 	"k8s.io/klog/v2"
 )
 
+// ConflictClass tells why a carry failed to cherry-pick.
+type ConflictClass string
+
+const (
+	// ConflictContent is a regular merge conflict
+	ConflictContent ConflictClass = "content"
+	// ConflictDeletedUpstream is a carry that edits a file upstream deleted
+	ConflictDeletedUpstream ConflictClass = "target file deleted upstream"
+)
+
 type CherryPickError struct {
 	message string
 	gitErr  error
+
+	class ConflictClass
+	paths []string
 }
 
 func (e *CherryPickError) Unwrap() error { return e.gitErr }
 func (e *CherryPickError) Error() string {
+	if e.class == ConflictDeletedUpstream {
+		return fmt.Sprintf("%s - %s: %v - %v", e.message, e.class, e.paths, e.gitErr)
+	}
 	return fmt.Sprintf("%s - %v", e.message, e.gitErr)
 }
 
+// Class returns the class of the conflict, it defaults to ConflictContent.
+func (e *CherryPickError) Class() ConflictClass {
+	if len(e.class) == 0 {
+		return ConflictContent
+	}
+	return e.class
+}
+
 type processor struct {
 	override                 carry.Prompt
 	git                      git.Git
 	github                   git.GitHub
+	runner                   *command.Runner
 	prompt                   carry.Prompt
 	target, marker, metadata string
 
@@ -32,13 +60,56 @@ type processor struct {
 	stopAtSHA string
 
 	cherryPickFromSHA, cherryStopAtSHA string
+
+	// base is the upstream tag of the previous rebase, if set the files
+	// upstream moved between base and the target are loaded by Init
+	base  string
+	moves *git.Moves
+
+	// when set, a conflicting carry is skipped and recorded in the inventory
+	keepGoingOnConflict bool
+	inventory           []conflicted
+
+	// guard records the pick in progress, resumed is the pick that
+	// stopped on a conflict in the previous run, completed by Init
+	guard   *workspace.Guard
+	mode    workspace.Mode
+	resumed *workspace.Pick
+
+	// hooks run after each carry is picked, the outcome of each carry
+	// is recorded in validated, a failed hook is recorded in the commit
+	// message with hookMark if the policy says so
+	hooks        Hooks
+	hookExecutor command.Executor
+	hookMark     string
+	validated    []validated
+
+	// a carry with an empty pick is absorbed upstream, it is committed
+	// as an empty commit only with keepEmpty
+	keepEmpty bool
+	absorbed  []absorbed
 }
 
-func (s *processor) Init() error {
-	if err := s.git.CheckRemotes(); err != nil {
+func (s *processor) Init(ctx context.Context) error {
+	if err := s.git.CheckRemotes(ctx); err != nil {
 		return fmt.Errorf("git repo not setup properly: %v", err)
 	}
 
+	if len(s.base) > 0 {
+		moves, err := s.git.Moves(ctx, s.base, s.stopAtSHA)
+		if err != nil {
+			return fmt.Errorf("failed to find files moved upstream since %s: %w", s.base, err)
+		}
+		klog.InfoS("files moved upstream", "base", s.base, "renamed", len(moves.Renamed), "deleted", len(moves.Deleted))
+		s.moves = moves
+	}
+
+	resumed, err := s.guard.Preflight(ctx, s.mode)
+	if err != nil {
+		return err
+	}
+	s.resumed = resumed
+
 	klog.InfoS("apply in progress", "target", s.target, "marker", s.marker, "rebase-marker-sha",
 		s.stopAtSHA, "commit-amend-metadata", s.metadata, "pick-cherry-picks-from", s.cherryPickFromSHA)
 
@@ -46,12 +117,42 @@ func (s *processor) Init() error {
 }
 
 func (s *processor) Done() error {
+	if s.resumed != nil {
+		return fmt.Errorf("the pick of %s was continued, but the carry is not in the carry commit log", s.resumed.Carry)
+	}
+	s.printValidated()
+	s.printAbsorbed()
+	if s.keepGoingOnConflict {
+		s.printInventory()
+		if len(s.inventory) > 0 {
+			return fmt.Errorf("%d carries were skipped due to conflicts", len(s.inventory))
+		}
+	}
+
 	klog.InfoS("apply has completed")
 	return nil
 }
 
-func (s *processor) Step(r *carry.CommitSummary) (DoFunc, error) {
+func (s *processor) Step(ctx context.Context, r *carry.CommitSummary) (DoFunc, error) {
+	if r.Override != nil {
+		klog.Infof("override matched: %s - %s", r.Override.String(), r.String())
+	}
+
+	do, err := s.step(r)
+	if err != nil || !s.keepGoingOnConflict {
+		return do, err
+	}
+	return s.keepGoing(do), nil
+}
+
+func (s *processor) step(r *carry.CommitSummary) (DoFunc, error) {
 	switch {
+	case r.Unit != nil && r.Unit.First(r):
+		return s.pickUnit, nil
+	case r.Unit != nil:
+		return s.unitMember, nil
+	case r.EffectiveType == "regenerate":
+		return s.regenerate, nil
 	case r.EffectiveType == "drop":
 		return s.drop, nil
 	case r.EffectiveType == "revert":
@@ -65,14 +166,14 @@ func (s *processor) Step(r *carry.CommitSummary) (DoFunc, error) {
 	return nil, fmt.Errorf("invalid commit type: %s", r.EffectiveType)
 }
 
-func (s *processor) picked(r *carry.CommitSummary) (bool, error) {
-	commits, err := s.git.Log("", s.stopAtSHA)
+func (s *processor) picked(ctx context.Context, r *carry.CommitSummary) (bool, error) {
+	commits, err := s.git.Log(ctx, "", s.stopAtSHA)
 	if err != nil {
 		return false, fmt.Errorf("git log failed with error: %w", err)
 	}
 
 	for _, commit := range commits {
-		if strings.Contains(commit.Message, fmt.Sprintf("%s=%s", s.metadata, r.SHA)) &&
+		if r.HasSHA(git.Metadata(commit.Message, s.metadata)) &&
 			strings.Contains(commit.Message, r.MessageWithPrefix) {
 			return true, nil
 		}
@@ -81,33 +182,28 @@ func (s *processor) picked(r *carry.CommitSummary) (bool, error) {
 	return false, nil
 }
 
-func (s *processor) cherrypicked(r *carry.CommitSummary) (bool, error) {
-	head, err := s.git.Head()
-	if err != nil {
-		return false, fmt.Errorf("failed to get HEAD: %w", err)
-	}
-
-	if strings.Contains(head.Message, r.MessageWithPrefix) {
-		// looks like conflict was resolved abd cherry-pick done
-		return true, nil
+// continued returns true if the given carry is the pick that stopped
+// on a conflict in the previous run, the preflight has completed it.
+func (s *processor) continued(r *carry.CommitSummary) bool {
+	if s.resumed == nil || !r.HasSHA(s.resumed.Carry) {
+		return false
 	}
-
-	return false, nil
+	s.resumed = nil
+	return true
 }
 
-func (s *processor) findCherryPickedCommit(r *carry.CommitSummary) (string, error) {
+func (s *processor) findCherryPickedCommit(ctx context.Context, r *carry.CommitSummary) (string, error) {
 	if len(s.cherryPickFromSHA) == 0 {
 		return "", nil
 	}
 
-	commits, err := s.git.Log(s.cherryPickFromSHA, s.cherryStopAtSHA)
+	commits, err := s.git.Log(ctx, s.cherryPickFromSHA, s.cherryStopAtSHA)
 	if err != nil {
 		return "", fmt.Errorf("git log failed with error: %w", err)
 	}
 
-	marker := fmt.Sprintf("%s=%s", s.metadata, r.SHA)
 	for _, commit := range commits {
-		if strings.Contains(commit.Message, marker) {
+		if r.HasSHA(git.Metadata(commit.Message, s.metadata)) {
 			return commit.Hash.String(), nil
 		}
 	}
@@ -115,37 +211,36 @@ func (s *processor) findCherryPickedCommit(r *carry.CommitSummary) (string, erro
 	return "", nil
 }
 
-func (s *processor) apply(r *carry.CommitSummary, cherrypick bool) error {
+func (s *processor) apply(ctx context.Context, r *carry.CommitSummary, cherrypick bool) error {
 	if cherrypick {
-		if err := s.git.CherryPick(r.SHA); err != nil {
-			// the cherry pick failed, possibly due to a conflict
-			// is there a branch from where we can pick it up?
-			var success bool
-			var cherryPickCommitSHA string
-			if cherryPickCommitSHA, err = s.findCherryPickedCommit(r); err != nil {
-				klog.Infof("did not find cherry-picked commit - %v", err)
-				return &CherryPickError{gitErr: err, message: r.String()}
-			}
+		head, err := s.git.Head(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get HEAD: %w", err)
+		}
+		if err := s.guard.Started(ctx, r.SHA, r.SHA); err != nil {
+			return err
+		}
 
-			if len(cherryPickCommitSHA) > 0 {
-				klog.InfoS("found a resolved commit, going to cherry pick", "sha", cherryPickCommitSHA)
-				s.git.AbortCherryPick()
-				if err := s.git.CherryPick(cherryPickCommitSHA); err != nil {
-					return &CherryPickError{gitErr: err, message: r.String()}
-				}
-				// successfully picked.
-				success = true
+		// a pick with nothing to commit stops as if it conflicted
+		pickErr := s.git.CherryPick(ctx, r.SHA)
+		empty, err := s.emptyPick(ctx, r, head.Hash.String(), pickErr != nil)
+		switch {
+		case err != nil:
+			return err
+		case empty:
+			if skip, err := s.absorb(ctx, r, head.Hash.String(), pickErr != nil); err != nil || skip {
+				return err
 			}
-
-			if !success {
-				return &CherryPickError{gitErr: err, message: r.String()}
+		case pickErr != nil:
+			if err := s.pickFailed(ctx, r, pickErr); err != nil {
+				return err
 			}
 		}
 	}
 
 	// chery-pick succeeded, now we need to append rebase metadata
 	// to the commit message
-	if err := s.git.AmendCommitMessage(func(current string) []string {
+	if err := s.git.AmendCommitMessage(ctx, func(current string) []string {
 		return []string{
 			removePreviousRebaseMetadata(current),
 			fmt.Sprintf("%s=%s", s.metadata, r.SHA),
@@ -154,39 +249,110 @@ func (s *processor) apply(r *carry.CommitSummary, cherrypick bool) error {
 		return fmt.Errorf("failed to amend commit message with rebase metadata - %w", err)
 	}
 
-	return nil
+	if err := s.guard.Finish(); err != nil {
+		return err
+	}
+	return s.validate(ctx, r)
 }
 
-func (s *processor) carry(r *carry.CommitSummary) error {
-	picked, err := s.picked(r)
+// pickFailed is invoked when a carry fails to cherry-pick, possibly due
+// to a conflict, the carry is picked from the branch with the resolved
+// commits, or with the paths upstream moved rewritten.
+func (s *processor) pickFailed(ctx context.Context, r *carry.CommitSummary, pickErr error) error {
+	// is there a branch from where we can pick it up?
+	cherryPickCommitSHA, err := s.findCherryPickedCommit(ctx, r)
 	if err != nil {
+		klog.Infof("did not find cherry-picked commit - %v", err)
+		return &CherryPickError{gitErr: err, message: r.String()}
+	}
+	if len(cherryPickCommitSHA) == 0 {
+		return s.pickMoved(ctx, r, pickErr)
+	}
+
+	klog.InfoS("found a resolved commit, going to cherry pick", "sha", cherryPickCommitSHA)
+	s.git.AbortCherryPick(ctx)
+	if err := s.guard.Started(ctx, r.SHA, cherryPickCommitSHA); err != nil {
 		return err
 	}
-	if picked {
-		klog.Infof("status=picked-in-branch do=noop - %s", r.String())
-		return nil
+	if err := s.git.CherryPick(ctx, cherryPickCommitSHA); err != nil {
+		return &CherryPickError{gitErr: err, message: r.String()}
+	}
+	return nil
+}
+
+// pickMoved is invoked when a carry fails to cherry-pick, and there is no
+// resolved commit to pick it from. If the carry touches paths upstream
+// moved since the previous base, the carry is picked again with its
+// paths rewritten. A carry that touches a path upstream deleted is
+// reported as a distinct class of conflict.
+func (s *processor) pickMoved(ctx context.Context, r *carry.CommitSummary, pickErr error) error {
+	if s.moves == nil {
+		return &CherryPickError{gitErr: pickErr, message: r.String()}
 	}
 
-	// did cherry pick abort last time due to conflict?
-	cherrypicked, err := s.cherrypicked(r)
+	touched, err := s.git.ChangedFiles(ctx, r.SHA)
+	if err != nil {
+		return fmt.Errorf("failed to list files changed by %s - %w", r.String(), err)
+	}
+	renamed, deleted := map[string]string{}, make([]string, 0)
+	for _, path := range touched {
+		if to, ok := s.moves.Renamed[path]; ok {
+			renamed[path] = to
+			continue
+		}
+		if s.moves.Deleted[path] {
+			deleted = append(deleted, path)
+		}
+	}
+
+	if len(renamed) > 0 {
+		klog.Infof("status=conflict do=pick-with-renames renames=%v - %s", renamed, r.String())
+		if err := s.git.AbortCherryPick(ctx); err != nil {
+			return err
+		}
+		if err := s.git.PickRewritten(ctx, r.SHA, renamed); err == nil {
+			klog.Infof("status=picked-with-renames renames=%v - %s", renamed, r.String())
+			return nil
+		}
+
+		// leave the conflict behind, as a plain cherry-pick would
+		klog.Infof("status=conflict(renames) do=cherry-pick - %s", r.String())
+		if pickErr = s.git.CherryPick(ctx, r.SHA); pickErr == nil {
+			return nil
+		}
+	}
+
+	if len(deleted) > 0 {
+		return &CherryPickError{gitErr: pickErr, message: r.String(), class: ConflictDeletedUpstream, paths: deleted}
+	}
+	return &CherryPickError{gitErr: pickErr, message: r.String()}
+}
+
+func (s *processor) carry(ctx context.Context, r *carry.CommitSummary) error {
+	picked, err := s.picked(ctx, r)
 	if err != nil {
 		return err
 	}
+	if picked {
+		klog.Infof("status=picked-in-branch do=noop - %s", r.String())
+		return nil
+	}
 
-	if cherrypicked {
+	// did cherry pick stop last time due to conflict?
+	if s.continued(r) {
 		klog.Infof("status=cherry-pick-completed do=apply-metadata - %s", r.String())
-		return s.apply(r, false)
+		return s.apply(ctx, r, false)
 	}
 
 	klog.Infof("status=not-picked-in-branch do=cherry-pick - %s", r.String())
-	if err := s.apply(r, true); err != nil {
+	if err := s.apply(ctx, r, true); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *processor) pick(r *carry.CommitSummary) error {
-	merged, err := s.github.IsPRMerged(r.UpstreamPR)
+func (s *processor) pick(ctx context.Context, r *carry.CommitSummary) error {
+	merged, err := s.isPRInTarget(ctx, r)
 	if err != nil {
 		return err
 	}
@@ -196,17 +362,116 @@ func (s *processor) pick(r *carry.CommitSummary) error {
 	}
 	klog.Infof("upstream PR(%s) status=not-merged - %s", r.UpstreamPR, r.MessageWithPrefix)
 
-	return s.carry(r)
+	return s.carry(ctx, r)
+}
+
+// isPRInTarget returns true if the upstream PR is already in the rebase
+// target. A kubernetes/kubernetes PR is in the target once it merges,
+// a PR in any other repository must also be in the version that is
+// vendored by the target.
+func (s *processor) isPRInTarget(ctx context.Context, r *carry.CommitSummary) (bool, error) {
+	pr, err := s.github.PullRequest(ctx, r.UpstreamPR)
+	if err != nil {
+		return false, err
+	}
+	if !pr.Merged || len(r.UpstreamRepo) == 0 || r.UpstreamRepo == "kubernetes/kubernetes" {
+		return pr.Merged, nil
+	}
+
+	// the marker commit has the tree of the target tag
+	gomod, err := s.git.ReadFile(ctx, s.stopAtSHA, "go.mod")
+	if err != nil {
+		return false, err
+	}
+	ref, local, err := git.VendoredRef(gomod, r.UpstreamRepo)
+	if err != nil {
+		return false, fmt.Errorf("failed to find the vendored version of %s - %w", r.UpstreamRepo, err)
+	}
+	if local {
+		// a staging repository, it is part of kubernetes/kubernetes
+		klog.Infof("upstream PR(%s) repo=%s is a staging repository - %s", r.UpstreamPR, r.UpstreamRepo, r.MessageWithPrefix)
+		return true, nil
+	}
+	if len(pr.MergeCommitSHA) == 0 {
+		return false, nil
+	}
+
+	included, err := s.github.IsCommitIncludedIn(ctx, r.UpstreamRepo, pr.MergeCommitSHA, ref)
+	if err != nil {
+		return false, err
+	}
+	if !included {
+		klog.Infof("upstream PR(%s) status=merged vendored=%s status=not-in-vendored-version - %s", r.UpstreamPR, ref, r.MessageWithPrefix)
+	}
+	return included, nil
 }
 
-func (s *processor) drop(r *carry.CommitSummary) error {
-	if drop := s.override.ShouldDrop(r.SHA); drop {
+// pickUnit applies all commits of a multi-commit upstream PR, the
+// merge check is done once for the unit, and the unit is either skipped
+// as a whole or picked in order. Once a commit of the unit is in the
+// branch, the rest of the unit is always picked, so a half-applied PR
+// is never left behind.
+func (s *processor) pickUnit(ctx context.Context, r *carry.CommitSummary) error {
+	unit := r.Unit
+
+	started := 0
+	for _, commit := range unit.Commits {
+		picked, err := s.picked(ctx, commit)
+		if err != nil {
+			return err
+		}
+		if !picked {
+			break
+		}
+		started++
+	}
+	if started == 0 {
+		// did cherry pick of the first commit stop last time due to conflict?
+		if s.resumed != nil && r.HasSHA(s.resumed.Carry) {
+			started = 1
+		}
+	}
+
+	if started == 0 {
+		merged, err := s.isPRInTarget(ctx, r)
+		if err != nil {
+			return err
+		}
+		if merged {
+			for _, commit := range unit.Commits {
+				klog.Infof("status=merged(upstream) do=skip(unit) - %s", commit.String())
+			}
+			return nil
+		}
+		klog.Infof("upstream PR(%s) status=not-merged - %s", unit.UpstreamPR, unit.String())
+	} else {
+		klog.Infof("status=unit-in-progress picked=%d/%d do=resume - %s", started, len(unit.Commits), unit.String())
+	}
+
+	for i, commit := range unit.Commits {
+		if err := s.carry(ctx, commit); err != nil {
+			return fmt.Errorf("%s is half-applied, %d/%d commits picked, resolve the conflict and run apply again to pick the rest - %w",
+				unit.String(), i, len(unit.Commits), err)
+		}
+	}
+	return nil
+}
+
+// unitMember is a noop, the commit is applied along with the first
+// commit of its unit.
+func (s *processor) unitMember(_ context.Context, r *carry.CommitSummary) error {
+	klog.V(2).Infof("status=applied-with-unit do=noop - %s", r.String())
+	return nil
+}
+
+func (s *processor) drop(ctx context.Context, r *carry.CommitSummary) error {
+	if drop := s.override.ShouldDrop(r); drop {
 		klog.Infof("status=drop(override) do=skip - %s", r.String())
 		return nil
 	}
 
 	klog.Infof("type=%s do=? - %s", r.EffectiveType, r.String())
-	drop, err := prompt(fmt.Sprintf("do you want to drop(%s)?[Yes/No]:", r.SHA))
+	drop, err := prompt(fmt.Sprintf("do you want to drop(%s)?[Yes/No]:", r.ShortSHA()))
 	if err != nil {
 		return err
 	}
@@ -216,11 +481,53 @@ func (s *processor) drop(r *carry.CommitSummary) error {
 		return nil
 	}
 
-	return s.carry(r)
+	return s.carry(ctx, r)
+}
+
+// regenerate runs the commands of the override instead of picking the
+// commit, the result is committed with the original message, so the
+// commit is regenerated against the target rather than cherry-picked.
+func (s *processor) regenerate(ctx context.Context, r *carry.CommitSummary) error {
+	picked, err := s.picked(ctx, r)
+	if err != nil {
+		return err
+	}
+	if picked {
+		klog.Infof("status=regenerated-in-branch do=noop - %s", r.String())
+		return nil
+	}
+
+	if r.Override == nil || len(r.Override.Commands) == 0 {
+		return fmt.Errorf("no command to regenerate %s", r.String())
+	}
+	head, err := s.git.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	klog.Infof("status=not-regenerated-in-branch do=regenerate commands=%d - %s", len(r.Override.Commands), r.String())
+	if err := s.runner.Run(ctx, r.Override.Commands); err != nil {
+		if ctx.Err() != nil {
+			// the commands were killed half-way, do not leave their
+			// partial changes behind in the working tree
+			klog.Infof("interrupted while regenerating %s, rolling back to %s", r.String(), head.Hash.String())
+			if resetErr := s.git.ResetHard(context.Background(), head.Hash.String()); resetErr != nil {
+				klog.ErrorS(resetErr, "failed to roll back the partial regeneration", "head", head.Hash.String())
+			}
+		}
+		return fmt.Errorf("failed to regenerate %s - %w", r.String(), err)
+	}
+
+	if err := s.git.CommitAll(ctx, []string{
+		r.MessageWithPrefix,
+		fmt.Sprintf("%s=%s", s.metadata, r.SHA),
+	}); err != nil {
+		return fmt.Errorf("failed to commit regenerated %s - %w", r.String(), err)
+	}
+	return nil
 }
 
-func (s *processor) revert(r *carry.CommitSummary) error {
-	return s.carry(r)
+func (s *processor) revert(ctx context.Context, r *carry.CommitSummary) error {
+	return s.carry(ctx, r)
 }
 
 func prompt(msg string) (bool, error) {
@@ -0,0 +1,89 @@
+package apply
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/command"
+	"github.com/tkashem/rebase/pkg/git"
+)
+
+// fakeGit implements the subset of git.Git the tests exercise.
+type fakeGit struct {
+	git.Git
+	log       []*gitv5object.Commit
+	committed [][]string
+}
+
+func (f *fakeGit) Log(_ context.Context, _, _ string) ([]*gitv5object.Commit, error) {
+	return f.log, nil
+}
+func (f *fakeGit) Head(_ context.Context) (*gitv5object.Commit, error) {
+	return &gitv5object.Commit{}, nil
+}
+func (f *fakeGit) CommitAll(_ context.Context, messages []string) error {
+	f.committed = append(f.committed, messages)
+	return nil
+}
+
+type fakeExecutor struct {
+	executed []string
+}
+
+func (f *fakeExecutor) Execute(_ context.Context, command string) ([]byte, error) {
+	f.executed = append(f.executed, command)
+	return nil, nil
+}
+
+func TestRegenerate(t *testing.T) {
+	metadata := "openshift-rebase(v1.24.0):source"
+	commit := &carry.CommitSummary{
+		SHA:               "c77caa826a0f3e9c8d76e4bd0dc1f7e2b8a4f1d2",
+		MessageWithPrefix: "UPSTREAM: <drop>: hack/update-codegen.sh",
+		EffectiveType:     "regenerate",
+		Override:          &carry.Override{Do: "regenerate", Commands: []string{"make update"}},
+	}
+
+	tests := []struct {
+		name      string
+		log       []*gitv5object.Commit
+		executed  []string
+		committed [][]string
+	}{
+		{
+			name:      "commands run, and the result is committed",
+			executed:  []string{"make update"},
+			committed: [][]string{{commit.MessageWithPrefix, metadata + "=" + commit.SHA}},
+		},
+		{
+			name: "already regenerated",
+			log: []*gitv5object.Commit{
+				{Message: commit.MessageWithPrefix + "\n\n" + metadata + "=" + commit.SHA},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, executor := &fakeGit{log: test.log}, &fakeExecutor{}
+			s := &processor{git: g, runner: &command.Runner{Executor: executor}, metadata: metadata}
+
+			do, err := s.Step(context.TODO(), commit)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if err := do(context.TODO(), commit); err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if !reflect.DeepEqual(test.executed, executor.executed) {
+				t.Errorf("Expected commands: %v, but got: %v", test.executed, executor.executed)
+			}
+			if !reflect.DeepEqual(test.committed, g.committed) {
+				t.Errorf("Expected commits: %v, but got: %v", test.committed, g.committed)
+			}
+		})
+	}
+}
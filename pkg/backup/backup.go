@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tkashem/rebase/pkg/git"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Prefix is where the backup refs of every target are kept
+	Prefix = "refs/rebase-backup/"
+
+	// the timestamp of a session, a ref name can not have a ':'
+	layout = "20060102-150405"
+)
+
+// Recorder records a backup ref of the branch before a command makes
+// its first change, and a checkpoint ref after every N carries:
+//
+//	refs/rebase-backup/{target}/{timestamp}
+//	refs/rebase-backup/{target}/{timestamp}-checkpoint-{N}
+//
+// A session that starts in the same second as an earlier one has a
+// counter after the timestamp, ie. {timestamp}-2.
+type Recorder struct {
+	git     git.Git
+	command string
+	target  string
+	session string
+	every   int
+
+	applied int
+	last    string
+}
+
+// NewRecorder returns a Recorder for a session of the given command,
+// no checkpoint is recorded if every is zero.
+func NewRecorder(gitAPI git.Git, command, target string, every int) *Recorder {
+	return &Recorder{
+		git:     gitAPI,
+		command: command,
+		target:  target,
+		session: sessionRef(target, time.Now()),
+		every:   every,
+	}
+}
+
+// Backup records the backup ref at HEAD.
+func (r *Recorder) Backup(ctx context.Context) error {
+	head, err := r.git.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	r.last = head.Hash.String()
+
+	// a session that started in the same second as an earlier one gets
+	// a counter, a backup ref is never overwritten
+	refs, err := r.git.ListRefs(ctx, targetPrefix(r.target))
+	if err != nil {
+		return err
+	}
+	exists := map[string]bool{}
+	for _, ref := range refs {
+		exists[ref.Name] = true
+	}
+	for n, session := 2, r.session; exists[r.session]; n++ {
+		r.session = fmt.Sprintf("%s-%d", session, n)
+	}
+
+	if err := r.git.CreateRef(ctx, r.session, r.last, fmt.Sprintf("%s: backup before the first change", r.command)); err != nil {
+		return fmt.Errorf("failed to record backup ref %s - %w", r.session, err)
+	}
+	klog.InfoS("recorded backup ref", "ref", r.session, "sha", r.last)
+	return nil
+}
+
+// Applied is invoked after each carry, a carry that did not move HEAD
+// is not counted.
+func (r *Recorder) Applied(ctx context.Context) error {
+	head, err := r.git.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if head.Hash.String() == r.last {
+		return nil
+	}
+	r.last = head.Hash.String()
+	r.applied++
+	if r.every <= 0 || r.applied%r.every != 0 {
+		return nil
+	}
+
+	ref := checkpointRef(r.session, r.applied)
+	if err := r.git.CreateRef(ctx, ref, r.last, fmt.Sprintf("%s: checkpoint after %d carries", r.command, r.applied)); err != nil {
+		return fmt.Errorf("failed to record checkpoint ref %s - %w", ref, err)
+	}
+	klog.InfoS("recorded checkpoint ref", "ref", ref, "sha", r.last)
+	return nil
+}
+
+func targetPrefix(target string) string { return Prefix + target + "/" }
+
+func sessionRef(target string, now time.Time) string {
+	return targetPrefix(target) + now.UTC().Format(layout)
+}
+
+func checkpointRef(session string, applied int) string {
+	return fmt.Sprintf("%s-checkpoint-%03d", session, applied)
+}
+
+// find returns the backup ref of the target with the given name, the
+// name is either the full ref, or relative to the target's prefix.
+func find(refs []git.Ref, target, name string) (git.Ref, error) {
+	full := name
+	if !strings.HasPrefix(name, "refs/") {
+		full = targetPrefix(target) + name
+	}
+	for _, ref := range refs {
+		if ref.Name == full {
+			return ref, nil
+		}
+	}
+	return git.Ref{}, fmt.Errorf("no backup ref %s for target %s, run rollback without --to to list them", name, target)
+}
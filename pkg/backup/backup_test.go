@@ -0,0 +1,116 @@
+package backup
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/git"
+)
+
+type fakeGit struct {
+	git.Git
+	head    string
+	refs    []git.Ref
+	updated []string
+}
+
+func (f *fakeGit) Head(_ context.Context) (*gitv5object.Commit, error) {
+	return &gitv5object.Commit{Hash: plumbing.NewHash(f.head)}, nil
+}
+func (f *fakeGit) ListRefs(_ context.Context, _ string) ([]git.Ref, error) {
+	return f.refs, nil
+}
+func (f *fakeGit) CreateRef(_ context.Context, name, _, _ string) error {
+	f.updated = append(f.updated, name)
+	return nil
+}
+
+func TestRecorder(t *testing.T) {
+	g := &fakeGit{head: "1111111111111111111111111111111111111111"}
+	session := sessionRef("v1.24", time.Date(2022, 5, 10, 14, 30, 0, 0, time.UTC))
+	r := &Recorder{git: g, command: "apply", target: "v1.24", session: session, every: 2}
+
+	if err := r.Backup(context.TODO()); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	// the second carry does not move HEAD, ie. it is merged upstream
+	for _, head := range []string{
+		"2222222222222222222222222222222222222222",
+		"2222222222222222222222222222222222222222",
+		"3333333333333333333333333333333333333333",
+		"4444444444444444444444444444444444444444",
+		"5555555555555555555555555555555555555555",
+	} {
+		g.head = head
+		if err := r.Applied(context.TODO()); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+
+	expected := []string{
+		"refs/rebase-backup/v1.24/20220510-143000",
+		"refs/rebase-backup/v1.24/20220510-143000-checkpoint-002",
+		"refs/rebase-backup/v1.24/20220510-143000-checkpoint-004",
+	}
+	if !reflect.DeepEqual(expected, g.updated) {
+		t.Errorf("Expected refs: %v, but got: %v", expected, g.updated)
+	}
+}
+
+func TestRecorderSameSecond(t *testing.T) {
+	session := sessionRef("v1.24", time.Date(2022, 5, 10, 14, 30, 0, 0, time.UTC))
+	g := &fakeGit{head: "1111111111111111111111111111111111111111", refs: []git.Ref{
+		{Name: session},
+		{Name: session + "-checkpoint-002"},
+		{Name: session + "-2"},
+	}}
+	r := &Recorder{git: g, command: "apply", target: "v1.24", session: session, every: 1}
+
+	if err := r.Backup(context.TODO()); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	g.head = "2222222222222222222222222222222222222222"
+	if err := r.Applied(context.TODO()); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	expected := []string{
+		"refs/rebase-backup/v1.24/20220510-143000-3",
+		"refs/rebase-backup/v1.24/20220510-143000-3-checkpoint-001",
+	}
+	if !reflect.DeepEqual(expected, g.updated) {
+		t.Errorf("Expected refs: %v, but got: %v", expected, g.updated)
+	}
+}
+
+func TestFind(t *testing.T) {
+	refs := []git.Ref{
+		{Name: "refs/rebase-backup/v1.24/20220510-143000", SHA: "1111111111111111111111111111111111111111"},
+		{Name: "refs/rebase-backup/v1.24/20220510-143000-checkpoint-010", SHA: "2222222222222222222222222222222222222222"},
+	}
+
+	tests := []struct {
+		name string
+		sha  string
+		err  bool
+	}{
+		{name: "20220510-143000-checkpoint-010", sha: "2222222222222222222222222222222222222222"},
+		{name: "refs/rebase-backup/v1.24/20220510-143000", sha: "1111111111111111111111111111111111111111"},
+		{name: "20220510-143000-checkpoint-020", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ref, err := find(refs, "v1.24", test.name)
+			if test.err != (err != nil) {
+				t.Fatalf("Expected error: %t, but got: %v", test.err, err)
+			}
+			if ref.SHA != test.sha {
+				t.Errorf("Expected: %s, but got: %s", test.sha, ref.SHA)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"github.com/tkashem/rebase/pkg/workspace"
+	"k8s.io/klog/v2"
+)
+
+func New(ctx context.Context, t *target.Target, to string) (*cmd, error) {
+	gitAPI, err := git.OpenWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+	guard, err := workspace.New(ctx, gitAPI, "rollback", t.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &cmd{
+		git:    gitAPI,
+		guard:  guard,
+		target: t.String(),
+		to:     to,
+		marker: t.Marker(),
+	}, nil
+}
+
+type cmd struct {
+	git                git.Git
+	guard              *workspace.Guard
+	target, to, marker string
+}
+
+func (c *cmd) Run(ctx context.Context) error {
+	refs, err := c.git.ListRefs(ctx, targetPrefix(c.target))
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return fmt.Errorf("no backup ref found for target %s", c.target)
+	}
+	if len(c.to) == 0 {
+		return c.list(ctx, refs)
+	}
+
+	ref, err := find(refs, c.target, c.to)
+	if err != nil {
+		return err
+	}
+	// the carries picked are found by walking back to the marker, a
+	// branch without it is not a rebase branch of the target
+	if _, err := c.git.FindRebaseMarkerCommit(ctx, ref.SHA, c.marker); err != nil {
+		return fmt.Errorf("%s is not a rebase branch of target %s - %w", ref.Name, c.target, err)
+	}
+
+	// the branch that is reset must be a rebase branch of the target
+	// too, and not any branch that happens to be checked out
+	branch, err := c.git.CurrentBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("rollback resets the current branch - %w", err)
+	}
+	if _, err := c.git.FindRebaseMarkerCommit(ctx, "", c.marker); err != nil {
+		return fmt.Errorf("the current branch %s is not a rebase branch of target %s - %w", branch, c.target, err)
+	}
+
+	if err := c.guard.Lock(); err != nil {
+		return err
+	}
+	defer c.guard.Unlock()
+
+	head, err := c.git.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	// the rollback itself can be rolled back
+	if err := NewRecorder(c.git, "rollback", c.target, 0).Backup(ctx); err != nil {
+		return err
+	}
+	if err := c.guard.Reset(ctx, ref.SHA); err != nil {
+		return fmt.Errorf("failed to roll back to %s - %w", ref.Name, err)
+	}
+	klog.InfoS("rollback has completed", "ref", ref.Name, "sha", ref.SHA, "previous-head", head.Hash.String())
+	return nil
+}
+
+func (c *cmd) list(ctx context.Context, refs []git.Ref) error {
+	head, err := c.git.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	b := &strings.Builder{}
+	for _, ref := range refs {
+		current := ""
+		if ref.SHA == head.Hash.String() {
+			current = " (HEAD)"
+		}
+		subject := ""
+		if commit, err := c.git.Commit(ctx, ref.SHA); err == nil {
+			subject = git.Subject(commit.Message)
+		}
+		fmt.Fprintf(b, "%s %s%s %s\n", strings.TrimPrefix(ref.Name, targetPrefix(c.target)), ref.SHA[:11], current, subject)
+	}
+	klog.Infof("backup refs of target %s, roll back with --to={name}:\n%s", c.target, b.String())
+	return nil
+}
@@ -0,0 +1,174 @@
+package bisect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/command"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"k8s.io/klog/v2"
+)
+
+func New(t *target.Target, cmdline string) (*cmd, error) {
+	gitAPI, err := git.OpenWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+	return &cmd{
+		git:      gitAPI,
+		target:   t.String(),
+		cmdline:  cmdline,
+		marker:   t.Marker(),
+		metadata: t.Metadata("source"),
+	}, nil
+}
+
+type cmd struct {
+	git              git.Git
+	target, cmdline  string
+	marker, metadata string
+}
+
+// Run finds the first carry between the rebase marker and HEAD the
+// command fails at. Each commit is checked out in a temporary worktree,
+// the working tree of the repository is untouched.
+func (c *cmd) Run(ctx context.Context) error {
+	marker, err := c.git.FindRebaseMarkerCommit(ctx, "", c.marker)
+	if err != nil {
+		return fmt.Errorf("rebase marker not found, this branch is not properly setup for rebase - %w", err)
+	}
+	commits, err := c.git.Log(ctx, "", marker.Hash.String())
+	if err != nil {
+		return fmt.Errorf("git log failed with error: %w", err)
+	}
+	// the last commit is the marker commit, and the oldest carry
+	// should be tested first
+	commits = commits[:len(commits)-1]
+	if len(commits) == 0 {
+		return fmt.Errorf("no commit between the rebase marker %s and HEAD", marker.Hash.String())
+	}
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+
+	dir, err := os.MkdirTemp("", "rebase-bisect-")
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary directory - %w", err)
+	}
+	worktree := filepath.Join(dir, "worktree")
+	if err := c.git.AddWorktree(ctx, worktree, marker.Hash.String()); err != nil {
+		return err
+	}
+	defer func() {
+		// the worktree is removed even if bisect was interrupted
+		if err := c.git.RemoveWorktree(context.Background(), worktree); err != nil {
+			klog.ErrorS(err, "failed to remove the temporary worktree", "worktree", worktree)
+		}
+	}()
+	klog.InfoS("bisect in progress", "target", c.target, "carries", len(commits), "worktree", worktree, "log-dir", dir)
+
+	executor := command.NewShellExecutor(worktree)
+	steps := 0
+	passes := func(i int) (bool, error) {
+		commit := marker
+		if i >= 0 {
+			commit = commits[i]
+		}
+		steps++
+		if err := c.git.CheckoutDetached(ctx, worktree, commit.Hash.String()); err != nil {
+			return false, err
+		}
+
+		logPath := filepath.Join(dir, fmt.Sprintf("%02d-%s.log", steps, commit.Hash.String()[:11]))
+		output, err := executor.Execute(ctx, c.cmdline)
+		if writeErr := os.WriteFile(logPath, output, 0644); writeErr != nil {
+			klog.ErrorS(writeErr, "failed to write the output of the command", "path", logPath)
+		}
+		var exitErr *exec.ExitError
+		switch {
+		case ctx.Err() != nil:
+			return false, fmt.Errorf("interrupted while testing %s - %w", commit.Hash.String(), ctx.Err())
+		case err == nil:
+			klog.Infof("step(%d) status=pass log=%s - %s %s", steps, logPath, commit.Hash.String()[:11], git.Subject(commit.Message))
+			return true, nil
+		case errors.As(err, &exitErr):
+			klog.Infof("step(%d) status=fail log=%s - %s %s", steps, logPath, commit.Hash.String()[:11], git.Subject(commit.Message))
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to run %q - %w", c.cmdline, err)
+	}
+
+	// the command is expected to fail at HEAD, and to pass at the
+	// marker, otherwise no carry is to blame
+	if ok, err := passes(len(commits) - 1); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%q passes at HEAD, there is nothing to bisect", c.cmdline)
+	}
+	if ok, err := passes(-1); err != nil || !ok {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%q fails at the rebase marker %s already, no carry is to blame", c.cmdline, marker.Hash.String())
+	}
+
+	i, err := search(len(commits), passes)
+	if err != nil {
+		return err
+	}
+	klog.Infof("the first commit %q fails at, after %d steps:\n%s", c.cmdline, steps, c.describe(commits[i]))
+	return nil
+}
+
+// describe returns the commit in the rebase branch, along with the
+// openshift commit, and the upstream PR of the carry it was picked from.
+func (c *cmd) describe(commit *gitv5object.Commit) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s\n", git.Subject(commit.Message))
+	fmt.Fprintf(b, "   commit: %s\n", commit.Hash.String())
+
+	source := git.Metadata(commit.Message, c.metadata)
+	if len(source) == 0 {
+		fmt.Fprintf(b, "   no %s metadata, it is not a carry commit\n", c.metadata)
+		return b.String()
+	}
+	summary, err := carry.Summarize(source, git.Subject(commit.Message))
+	if err != nil {
+		fmt.Fprintf(b, "   openshift: https://github.com/openshift/kubernetes/commit/%s\n", source)
+		return b.String()
+	}
+	fmt.Fprintf(b, "   openshift: %s\n", summary.OpenShiftCommit)
+	if len(summary.UpstreamPR) > 0 {
+		fmt.Fprintf(b, "   upstream: %s\n", summary.UpstreamPR)
+	}
+	return b.String()
+}
+
+// search returns the index of the first commit the test fails at, the
+// commit before the first one is known to pass, and the last one is
+// known to fail.
+func search(n int, passes func(i int) (bool, error)) (int, error) {
+	good, bad := -1, n-1
+	for bad-good > 1 {
+		mid := good + (bad-good)/2
+		ok, err := passes(mid)
+		if err != nil {
+			return -1, err
+		}
+		if ok {
+			good = mid
+			continue
+		}
+		bad = mid
+	}
+	return bad, nil
+}
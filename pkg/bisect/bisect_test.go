@@ -0,0 +1,45 @@
+package bisect
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		// the first commit the test fails at
+		first int
+		// the maximum number of steps a binary search takes
+		steps int
+	}{
+		{name: "a single commit", n: 1, first: 0, steps: 0},
+		{name: "the first commit", n: 160, first: 0, steps: 8},
+		{name: "a commit in the middle", n: 160, first: 79, steps: 8},
+		{name: "the last commit", n: 160, first: 159, steps: 8},
+		{name: "two commits", n: 2, first: 1, steps: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			steps := 0
+			got, err := search(test.n, func(i int) (bool, error) {
+				steps++
+				if i < 0 || i >= test.n-1 {
+					return false, fmt.Errorf("commit %d is known, it should not be tested", i)
+				}
+				return i < test.first, nil
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if got != test.first {
+				t.Errorf("Expected the first failing commit: %d, but got: %d", test.first, got)
+			}
+			if steps > test.steps {
+				t.Errorf("Expected at most %d steps, but got: %d", test.steps, steps)
+			}
+		})
+	}
+}
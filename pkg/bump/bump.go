@@ -0,0 +1,126 @@
+package bump
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"k8s.io/klog/v2"
+)
+
+const (
+	dockerfiles = "openshift-hack/images/*/Dockerfile.rhel"
+	golangSh    = "hack/lib/golang.sh"
+
+	message = "UPSTREAM: <drop>: Update hyperkube dockerfile version"
+)
+
+func New(tag, golang, openshift string, noCommit bool) (*cmd, error) {
+	gitAPI, err := git.OpenWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &cmd{
+		git:       gitAPI,
+		tag:       tag,
+		golang:    golang,
+		openshift: openshift,
+		noCommit:  noCommit,
+	}, nil
+}
+
+type cmd struct {
+	git                    git.Git
+	tag, golang, openshift string
+	noCommit               bool
+}
+
+func (c *cmd) Run(ctx context.Context) error {
+	t, err := target.Parse(c.tag)
+	if err != nil {
+		return err
+	}
+	if t.IsLine() {
+		return fmt.Errorf("%s is a release line, the version label needs a tag ie. v1.24.0", c.tag)
+	}
+	version := t.Release()
+	klog.InfoS("bump-version in progress", "tag", c.tag, "version", version, "golang", c.golang, "openshift", c.openshift)
+
+	files, err := filepath.Glob(dockerfiles)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no file matches %s, is this the root of openshift/kubernetes?", dockerfiles)
+	}
+
+	// the go version of the builder image of each Dockerfile
+	edited, builders := map[string][]byte{}, map[string]string{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %q - %w", file, err)
+		}
+		out, golang, err := editDockerfile(content, version, c.golang, c.openshift)
+		if err != nil {
+			return fmt.Errorf("failed to edit %q - %w", file, err)
+		}
+		if len(golang) > 0 {
+			builders[file] = golang
+		}
+		if !bytes.Equal(content, out) {
+			edited[file] = out
+		}
+	}
+
+	if len(builders) > 0 {
+		gomod, err := c.git.ReadFile(ctx, c.tag, "go.mod")
+		if err != nil {
+			return fmt.Errorf("failed to read go.mod of %s - %w", c.tag, err)
+		}
+		sh, err := c.git.ReadFile(ctx, c.tag, golangSh)
+		if err != nil && !errors.Is(err, gitv5object.ErrFileNotFound) {
+			return fmt.Errorf("failed to read %s of %s - %w", golangSh, c.tag, err)
+		}
+		for _, file := range files {
+			golang, ok := builders[file]
+			if !ok {
+				continue
+			}
+			if err := validateGo(golang, gomod, sh); err != nil {
+				return fmt.Errorf("invalid builder image in %q for %s - %w", file, c.tag, err)
+			}
+		}
+	}
+
+	if len(edited) == 0 {
+		klog.Infof("bump-version: %s is already at kubernetes=%s, nothing to commit", dockerfiles, version)
+		return nil
+	}
+
+	paths := make([]string, 0, len(edited))
+	for _, file := range files {
+		out, ok := edited[file]
+		if !ok {
+			continue
+		}
+		if err := os.WriteFile(file, out, 0644); err != nil {
+			return fmt.Errorf("failed to write %q - %w", file, err)
+		}
+		klog.Infof("bump-version: %s kubernetes=%s golang=%s", file, version, builders[file])
+		paths = append(paths, file)
+	}
+
+	if c.noCommit {
+		klog.Infof("bump-version: %d file(s) edited, not committed", len(paths))
+		return nil
+	}
+	return c.git.CommitFiles(ctx, paths, []string{message})
+}
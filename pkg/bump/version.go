@@ -0,0 +1,111 @@
+package bump
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+var (
+	// LABEL io.openshift.build.versions="kubernetes=1.24.0"
+	label = regexp.MustCompile(`(io\.openshift\.build\.versions="kubernetes=)[^"]*(")`)
+	// FROM registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.17-openshift-4.10 AS builder
+	builder = regexp.MustCompile(`(builder:rhel-\d+-golang-)(\d+\.\d+)(-openshift-)(\d+\.\d+)`)
+	// minimum_go_version=go1.18.0
+	minimumGo = regexp.MustCompile(`minimum_go_version=go(\d+\.\d+(\.\d+)?)`)
+)
+
+// goVersion is the major.minor of a Go release
+type goVersion struct {
+	major, minor int
+}
+
+func (v goVersion) String() string { return fmt.Sprintf("%d.%d", v.major, v.minor) }
+
+func (v goVersion) less(other goVersion) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	return v.minor < other.minor
+}
+
+// parseGo parses 1.18, 1.18.1 or go1.18.1, the patch is ignored.
+func parseGo(s string) (goVersion, error) {
+	split := strings.Split(strings.TrimPrefix(s, "go"), ".")
+	if len(split) < 2 || len(split) > 3 {
+		return goVersion{}, fmt.Errorf("%q is not a go version, ie. 1.18", s)
+	}
+	major, err := strconv.Atoi(split[0])
+	if err != nil {
+		return goVersion{}, fmt.Errorf("%q is not a go version, ie. 1.18", s)
+	}
+	minor, err := strconv.Atoi(split[1])
+	if err != nil {
+		return goVersion{}, fmt.Errorf("%q is not a go version, ie. 1.18", s)
+	}
+	return goVersion{major: major, minor: minor}, nil
+}
+
+// editDockerfile sets the kubernetes version label, and the golang and
+// openshift versions of the builder image, an empty value is left as is.
+// It returns the go version of the builder image after the edit.
+func editDockerfile(content []byte, version, golang, openshift string) ([]byte, string, error) {
+	if !label.Match(content) {
+		return nil, "", fmt.Errorf("no kubernetes version label")
+	}
+	content = label.ReplaceAll(content, []byte("${1}"+version+"${2}"))
+
+	matches := builder.FindSubmatch(content)
+	if matches == nil {
+		if len(golang) > 0 || len(openshift) > 0 {
+			return nil, "", fmt.Errorf("no builder image")
+		}
+		return content, "", nil
+	}
+	if len(golang) == 0 {
+		golang = string(matches[2])
+	}
+	if len(openshift) == 0 {
+		openshift = string(matches[4])
+	}
+	content = builder.ReplaceAll(content, []byte("${1}"+golang+"${3}"+openshift))
+	return content, golang, nil
+}
+
+// validateGo checks that the go version of the builder image satisfies
+// both the go directive of the target's go.mod, and the minimum go
+// version kubernetes builds with.
+func validateGo(golang string, gomod, golangSh []byte) error {
+	builderGo, err := parseGo(golang)
+	if err != nil {
+		return err
+	}
+
+	f, err := modfile.ParseLax("go.mod", gomod, nil)
+	if err != nil {
+		return fmt.Errorf("failed to parse go.mod - %w", err)
+	}
+	if f.Go != nil {
+		required, err := parseGo(f.Go.Version)
+		if err != nil {
+			return err
+		}
+		if builderGo.less(required) {
+			return fmt.Errorf("builder go %s is older than go %s required by go.mod", builderGo, required)
+		}
+	}
+
+	if matches := minimumGo.FindSubmatch(golangSh); matches != nil {
+		minimum, err := parseGo(string(matches[1]))
+		if err != nil {
+			return err
+		}
+		if builderGo.less(minimum) {
+			return fmt.Errorf("builder go %s is older than minimum_go_version go%s in hack/lib/golang.sh", builderGo, matches[1])
+		}
+	}
+	return nil
+}
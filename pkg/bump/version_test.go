@@ -0,0 +1,55 @@
+package bump
+
+import (
+	"testing"
+)
+
+func TestEditDockerfile(t *testing.T) {
+	dockerfile := `FROM registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.17-openshift-4.10 AS builder
+RUN make WHAT='cmd/kube-apiserver'
+
+FROM registry.ci.openshift.org/ocp/4.10:base
+LABEL io.k8s.display-name="OpenShift Kubernetes Server Commands" \
+      io.openshift.build.versions="kubernetes=1.23.3"
+`
+	want := `FROM registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.18-openshift-4.10 AS builder
+RUN make WHAT='cmd/kube-apiserver'
+
+FROM registry.ci.openshift.org/ocp/4.10:base
+LABEL io.k8s.display-name="OpenShift Kubernetes Server Commands" \
+      io.openshift.build.versions="kubernetes=1.24.0"
+`
+
+	got, golang, err := editDockerfile([]byte(dockerfile), "1.24.0", "1.18", "")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", want, got)
+	}
+	if golang != "1.18" {
+		t.Errorf("Expected builder go: 1.18, but got: %s", golang)
+	}
+}
+
+func TestValidateGo(t *testing.T) {
+	gomod := []byte("module k8s.io/kubernetes\n\ngo 1.16\n")
+	golangSh := []byte("  local minimum_go_version=go1.18.0\n")
+
+	tests := []struct {
+		golang string
+		err    bool
+	}{
+		{golang: "1.18"},
+		{golang: "1.19"},
+		{golang: "1.17", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.golang, func(t *testing.T) {
+			err := validateGo(test.golang, gomod, golangSh)
+			if test.err != (err != nil) {
+				t.Errorf("Expected error: %t, but got: %v", test.err, err)
+			}
+		})
+	}
+}
@@ -1,33 +1,109 @@
 package carry
 
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/klog/v2"
+)
+
 type CommitReader interface {
-	Read() ([]*CommitSummary, error)
+	Read(ctx context.Context) ([]*CommitSummary, error)
+}
+
+// RevertReader is implemented by a CommitReader that leaves out the
+// carry commits cancelled by a later revert in the carry commit log.
+type RevertReader interface {
+	Reverts() []RevertPair
 }
 
-func NewReaderFromFile(fpath, overrides string) (CommitReader, error) {
-	overrider, err := newOverrider(overrides)
+// Resolver expands an abbreviated SHA to the full object ID of the
+// commit, it fails if the prefix is missing or ambiguous.
+type Resolver interface {
+	ResolveSHA(ctx context.Context, sha string) (string, error)
+}
+
+// Repository is the view of the git repository the carry commits
+// are read against.
+type Repository interface {
+	Resolver
+	ChangeLister
+	MessageReader
+}
+
+func NewReaderFromFile(ctx context.Context, fpath, overrides string, repository Repository) (CommitReader, error) {
+	var changes ChangeLister
+	var resolver Resolver
+	var messages MessageReader
+	if repository != nil {
+		changes, resolver, messages = repository, repository, repository
+	}
+
+	overrider, err := newOverrider(ctx, overrides, changes, resolver)
 	if err != nil {
 		return nil, err
 	}
 
 	return &carry{
 		reader:    &csvReader{fpath: fpath},
+		resolver:  resolver,
+		messages:  messages,
 		overrider: overrider,
 	}, nil
 }
 
 type carry struct {
 	reader    CommitReader
+	resolver  Resolver
+	messages  MessageReader
 	overrider Overrider
+	reverts   []RevertPair
 }
 
-func (c *carry) Read() ([]*CommitSummary, error) {
-	commits, err := c.reader.Read()
+func (c *carry) Reverts() []RevertPair { return c.reverts }
+
+func (c *carry) Read(ctx context.Context) ([]*CommitSummary, error) {
+	commits, err := c.reader.Read(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	// resolve the abbreviated SHAs, so every comparison from
+	// here on is done on the full object ID.
+	if err := resolve(ctx, c.resolver, commits); err != nil {
+		return nil, err
+	}
+
 	// apply override, before we start processing
-	c.overrider.Override(commits)
+	if err := c.overrider.Override(ctx, commits); err != nil {
+		return nil, err
+	}
+
+	// a carry commit and its revert cancel each other out, unless an
+	// override names either of them by SHA
+	commits, c.reverts, err = cancelReverts(ctx, commits, c.messages, c.resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	// commits of a multi-commit upstream PR are applied as a unit
+	groupUnits(commits)
 	return commits, nil
 }
+
+func resolve(ctx context.Context, resolver Resolver, commits []*CommitSummary) error {
+	if resolver == nil {
+		klog.InfoS("resolve: no git repository, using the SHAs from the carry commit log as is")
+		return nil
+	}
+
+	for i := range commits {
+		commit := commits[i]
+		full, err := resolver.ResolveSHA(ctx, commit.SHA)
+		if err != nil {
+			return fmt.Errorf("failed to resolve carry commit %s - %w", commit.String(), err)
+		}
+		commit.AbbreviatedSHA, commit.SHA = commit.SHA, full
+	}
+	return nil
+}
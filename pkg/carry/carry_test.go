@@ -0,0 +1,108 @@
+package carry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeResolver maps the full object ID of each object in the
+// repository to its type, only commits are resolved, like git does.
+type fakeResolver map[string]string
+
+func (f fakeResolver) ResolveSHA(_ context.Context, sha string) (string, error) {
+	matches := make([]string, 0)
+	for object, kind := range f {
+		if kind == "commit" && strings.HasPrefix(object, sha) {
+			matches = append(matches, object)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("commit %s not found", sha)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("commit %s is ambiguous, candidates: %v", sha, matches)
+}
+
+func TestResolve(t *testing.T) {
+	objects := fakeResolver{
+		"d7b268fffba0e2bd04e2b1b3a5a67c0b1f6d8e11": "commit",
+		"c77caa826a0b4e6b30a4d5f0d1c47f1e3b1a9f22": "commit",
+		"c77caa826a0f9a1e7b2c3d4e5f60718293a4b533": "commit",
+		"8bd488b66eb1c2d3e4f5061728394a5b6c7d8e44": "tree",
+		"d7b268fffbb9c8d7e6f5a4b3c2d1e0f9a8b7c655": "blob",
+	}
+
+	tests := []struct {
+		name     string
+		resolver Resolver
+		commits  []string
+		expected []string
+		err      string
+	}{
+		{
+			name:     "abbreviated SHA is resolved, objects that are not commits are ignored",
+			resolver: objects,
+			commits:  []string{"d7b268fffb"},
+			expected: []string{"d7b268fffba0e2bd04e2b1b3a5a67c0b1f6d8e11"},
+		},
+		{
+			name:     "missing prefix",
+			resolver: objects,
+			commits:  []string{"d7b268fffba", "0123456789a"},
+			err:      "commit 0123456789a not found",
+		},
+		{
+			name:     "ambiguous prefix",
+			resolver: objects,
+			commits:  []string{"c77caa826a0"},
+			err:      "commit c77caa826a0 is ambiguous",
+		},
+		{
+			name:     "the prefix matches a tree object only",
+			resolver: objects,
+			commits:  []string{"8bd488b66eb"},
+			err:      "commit 8bd488b66eb not found",
+		},
+		{
+			name:     "no git repository, the SHAs are used as is",
+			commits:  []string{"0123456789a"},
+			expected: []string{"0123456789a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commits := make([]*CommitSummary, 0)
+			for _, sha := range test.commits {
+				commits = append(commits, &CommitSummary{SHA: sha, MessageWithPrefix: "UPSTREAM: <carry>: " + sha})
+			}
+
+			err := resolve(context.TODO(), test.resolver, commits)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Expected error: %q, but got: %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+
+			got := make([]string, 0)
+			for i, commit := range commits {
+				got = append(got, commit.SHA)
+				if test.resolver != nil && commit.AbbreviatedSHA != test.commits[i] {
+					t.Errorf("Expected the abbreviated SHA: %s, but got: %s", test.commits[i], commit.AbbreviatedSHA)
+				}
+			}
+			if !reflect.DeepEqual(test.expected, got) {
+				t.Errorf("Expected SHAs: %v, but got: %v", test.expected, got)
+			}
+		})
+	}
+}
@@ -1,43 +1,185 @@
 package carry
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"regexp"
+	"strings"
 
 	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/klog/v2"
 )
 
 type Overrider interface {
-	Override([]*CommitSummary)
+	Override(context.Context, []*CommitSummary) error
 }
 
-func newOverrider(fpath string) (Overrider, error) {
+// ChangeLister returns the paths touched by a given commit, it is
+// used to evaluate the path globs of an override rule.
+type ChangeLister interface {
+	ChangedFiles(ctx context.Context, sha string) ([]string, error)
+}
+
+func newOverrider(ctx context.Context, fpath string, changes ChangeLister, resolver Resolver) (Overrider, error) {
 	if len(fpath) == 0 {
 		return noOverride{}, nil
 	}
 
-	return newOverriderFromFile(fpath)
+	return newOverriderFromFile(ctx, fpath, changes, resolver)
 }
 
 type noOverride struct{}
 
-func (noOverride) Override(_ []*CommitSummary) {
+func (noOverride) Override(_ context.Context, _ []*CommitSummary) error {
 	klog.InfoS("override: none specified")
+	return nil
 }
 
+// Override is a rule that changes the effective type of a carry commit.
+// A rule either matches a commit by its exact SHA, or by a pattern:
+//   - message: a regular expression matched against the commit subject
+//     with the 'UPSTREAM: ' prefix
+//   - paths: a list of globs, every file touched by the commit must
+//     match at least one of them, a glob ending with '/**' matches
+//     anything underneath the directory
+//   - type: the original type of the commit, ie. carry, drop
+//
+// When a pattern rule specifies more than one of the above, all of
+// them must match.
 type Override struct {
-	SHA string `json:"sha,omitempty"`
-	Do  string `json:"do,omitempty"`
+	SHA     string   `json:"sha,omitempty"`
+	Message string   `json:"message,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+	Type    string   `json:"type,omitempty"`
+	Do      string   `json:"do,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+
+	// Commands are run, in order, to regenerate the content of a
+	// commit, it is used with the 'regenerate' action only.
+	Commands []string `json:"commands,omitempty"`
+
+	// Comments are the comment lines that precede the rule in the
+	// override file, they usually explain why the rule exists.
+	Comments []string `json:"-"`
+
+	// index of the rule in the override file
+	index   int
+	message *regexp.Regexp
+}
+
+func (o *Override) String() string {
+	if len(o.SHA) > 0 {
+		return fmt.Sprintf("rule[%d] sha: %s, action: %s", o.index, o.SHA, o.Do)
+	}
+	return fmt.Sprintf("rule[%d] message: %q, paths: %v, type: %q, action: %s", o.index, o.Message, o.Paths, o.Type, o.Do)
+}
+
+func (o *Override) compile(index int) error {
+	o.index = index
+	if len(o.Do) == 0 {
+		return fmt.Errorf("override rule[%d] does not specify an action", index)
+	}
+	if (o.Do == "regenerate") != (len(o.Commands) > 0) {
+		return fmt.Errorf("override rule[%d] must specify commands with, and only with, the regenerate action", index)
+	}
+
+	if len(o.SHA) > 0 {
+		if len(o.Message) > 0 || len(o.Paths) > 0 || len(o.Type) > 0 {
+			return fmt.Errorf("override rule[%d] must not combine sha with a pattern", index)
+		}
+		return nil
+	}
+
+	if len(o.Message) == 0 && len(o.Paths) == 0 && len(o.Type) == 0 {
+		return fmt.Errorf("override rule[%d] must specify either sha, message, paths or type", index)
+	}
+	if len(o.Message) > 0 {
+		regex, err := regexp.Compile(o.Message)
+		if err != nil {
+			return fmt.Errorf("override rule[%d] has an invalid message regex - %w", index, err)
+		}
+		o.message = regex
+	}
+	for _, glob := range o.Paths {
+		if _, err := path.Match(strings.TrimSuffix(glob, "/**"), ""); err != nil {
+			return fmt.Errorf("override rule[%d] has an invalid path glob %q - %w", index, glob, err)
+		}
+	}
+	if len(o.Type) > 0 {
+		t, err := sanitize(o.Type)
+		if err != nil {
+			return fmt.Errorf("override rule[%d] has an invalid type - %w", index, err)
+		}
+		o.Type = t
+	}
+
+	return nil
+}
+
+func (o *Override) matches(ctx context.Context, commit *CommitSummary, changes ChangeLister) (bool, error) {
+	if len(o.SHA) > 0 {
+		return commit.HasSHA(o.SHA), nil
+	}
+
+	if len(o.Type) > 0 && o.Type != commit.OriginalType {
+		return false, nil
+	}
+	if o.message != nil && !o.message.MatchString(commit.MessageWithPrefix) {
+		return false, nil
+	}
+	if len(o.Paths) == 0 {
+		return true, nil
+	}
+
+	if changes == nil {
+		return false, fmt.Errorf("%s: can not match paths, no git repository", o.String())
+	}
+	files, err := changes.ChangedFiles(ctx, commit.SHA)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to list files changed by %s - %w", o.String(), commit.SHA, err)
+	}
+	if len(files) == 0 {
+		return false, nil
+	}
+	for _, file := range files {
+		if !matchAny(o.Paths, file) {
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
-func (o *Override) String() string { return fmt.Sprintf("sha: %s, action: %s", o.SHA, o.Do) }
+func matchAny(globs []string, file string) bool {
+	for _, glob := range globs {
+		if dir := strings.TrimSuffix(glob, "/**"); dir != glob {
+			if matched, _ := path.Match(dir, file); matched {
+				return true
+			}
+			// match the directory itself, or any of its parents
+			for parent := path.Dir(file); parent != "." && parent != "/"; parent = path.Dir(parent) {
+				if matched, _ := path.Match(dir, parent); matched {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := path.Match(glob, file); matched {
+			return true
+		}
+	}
+	return false
+}
 
 type overrider struct {
 	overrides []Override
+	bySHA     map[string]*Override
+	changes   ChangeLister
 }
 
-func newOverriderFromFile(fpath string) (*overrider, error) {
+func readOverrides(fpath string) ([]Override, error) {
 	stat, err := os.Stat(fpath)
 	if err != nil {
 		return nil, fmt.Errorf("error loading file %q - %w", fpath, err)
@@ -63,28 +205,112 @@ func newOverriderFromFile(fpath string) (*overrider, error) {
 		return nil, fmt.Errorf("failed to decode overrides from %q - %w", fpath, err)
 	}
 
-	return &overrider{overrides: o.Overrides}, nil
+	for i := range o.Overrides {
+		if err := o.Overrides[i].compile(i); err != nil {
+			return nil, fmt.Errorf("invalid overrides in %q - %w", fpath, err)
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error loading file %q - %w", fpath, err)
+	}
+	comments, err := readComments(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comments from %q - %w", fpath, err)
+	}
+	for i := range o.Overrides {
+		if i < len(comments) {
+			o.Overrides[i].Comments = comments[i]
+		}
+	}
+	return o.Overrides, nil
 }
 
-func (o *overrider) Override(commits []*CommitSummary) {
+// LoadOverrides reads the override rules, along with their comments,
+// from the given file.
+func LoadOverrides(fpath string) ([]Override, error) {
+	return readOverrides(fpath)
+}
+
+func newOverriderFromFile(ctx context.Context, fpath string, changes ChangeLister, resolver Resolver) (*overrider, error) {
+	overrides, err := readOverrides(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	// the carry commits are resolved to full object IDs, so are the rules
+	if resolver != nil {
+		for i := range overrides {
+			override := &overrides[i]
+			if len(override.SHA) == 0 {
+				continue
+			}
+			full, err := resolver.ResolveSHA(ctx, override.SHA)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s in %q - %w", override.String(), fpath, err)
+			}
+			override.SHA = full
+		}
+	}
+
+	return &overrider{overrides: overrides, bySHA: toMap(overrides), changes: changes}, nil
+}
+
+func (o *overrider) Override(ctx context.Context, commits []*CommitSummary) error {
 	klog.Infof("override: %d specified", len(o.overrides))
-	overrides := toMap(o.overrides)
 
 	for i := range commits {
 		commit := commits[i]
-		if override, ok := overrides[commit.SHA]; ok {
-			klog.Infof("override(%s): %s->%s\t%s", commit.SHA, commit.EffectiveType, override.Do, commit.MessageWithPrefix)
+		override, err := o.match(ctx, commit)
+		if err != nil {
+			return err
+		}
+		if override == nil {
+			continue
+		}
+
+		klog.Infof("override(%s): %s->%s\t%s\tmatched: %s", commit.ShortSHA(), commit.EffectiveType, override.Do, commit.MessageWithPrefix, override.String())
+
+		// we are override the type here, we keep OriginalType intact
+		commit.EffectiveType = override.Do
+		commit.Override = override
+	}
+
+	return nil
+}
+
+// match returns the rule that applies to the given commit, an exact SHA
+// match always takes precedence over a pattern, otherwise the first
+// pattern in file order wins.
+func (o *overrider) match(ctx context.Context, commit *CommitSummary) (*Override, error) {
+	if override, ok := o.bySHA[commit.SHA]; ok {
+		return override, nil
+	}
 
-			// we are override the type here, we keep OriginalType intact
-			commit.EffectiveType = override.Do
+	for i := range o.overrides {
+		override := &o.overrides[i]
+		if len(override.SHA) > 0 {
+			continue
+		}
+		matched, err := override.matches(ctx, commit, o.changes)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return override, nil
 		}
 	}
+
+	return nil, nil
 }
 
-func toMap(overrides []Override) map[string]Override {
-	m := map[string]Override{}
+func toMap(overrides []Override) map[string]*Override {
+	m := map[string]*Override{}
 	for i := range overrides {
-		override := overrides[i]
+		override := &overrides[i]
+		if len(override.SHA) == 0 {
+			continue
+		}
 		m[override.SHA] = override
 	}
 	return m
@@ -0,0 +1,128 @@
+package carry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeChangeLister map[string][]string
+
+func (f fakeChangeLister) ChangedFiles(_ context.Context, sha string) ([]string, error) {
+	return f[sha], nil
+}
+
+func TestOverride(t *testing.T) {
+	changes := fakeChangeLister{
+		"c77caa826a0": {"vendor/modules.txt", "vendor/k8s.io/klog/v2/klog.go"},
+		"d7b268fffba": {"vendor/modules.txt", "pkg/kubelet/kubelet.go"},
+	}
+
+	tests := []struct {
+		name      string
+		overrides []Override
+		commit    *CommitSummary
+		expected  string
+		matched   int
+	}{
+		{
+			name: "exact SHA beats pattern",
+			overrides: []Override{
+				{Message: "update vendor", Do: "carry"},
+				{SHA: "c77caa826a0", Do: "drop"},
+			},
+			commit:   &CommitSummary{SHA: "c77caa826a0", OriginalType: "drop", EffectiveType: "drop", MessageWithPrefix: "UPSTREAM: <drop>: update vendor files"},
+			expected: "drop",
+			matched:  1,
+		},
+		{
+			name: "patterns apply in file order",
+			overrides: []Override{
+				{Message: `^UPSTREAM: <drop>: .*vendor`, Do: "drop"},
+				{Type: "drop", Do: "carry"},
+			},
+			commit:   &CommitSummary{SHA: "c77caa826a0", OriginalType: "drop", EffectiveType: "drop", MessageWithPrefix: "UPSTREAM: <drop>: update vendor files"},
+			expected: "drop",
+			matched:  0,
+		},
+		{
+			name: "paths must cover every touched file",
+			overrides: []Override{
+				{Paths: []string{"vendor/**"}, Do: "drop"},
+			},
+			commit:   &CommitSummary{SHA: "d7b268fffba", OriginalType: "carry", EffectiveType: "carry", MessageWithPrefix: "UPSTREAM: <carry>: kubelet"},
+			expected: "carry",
+			matched:  -1,
+		},
+		{
+			name: "paths and type combined",
+			overrides: []Override{
+				{Paths: []string{"vendor/**"}, Type: "carry", Do: "drop"},
+				{Paths: []string{"vendor/**"}, Type: "<drop>", Do: "drop"},
+			},
+			commit:   &CommitSummary{SHA: "c77caa826a0", OriginalType: "drop", EffectiveType: "drop", MessageWithPrefix: "UPSTREAM: <drop>: update vendor files"},
+			expected: "drop",
+			matched:  1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := range test.overrides {
+				if err := test.overrides[i].compile(i); err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+			}
+			o := &overrider{overrides: test.overrides, bySHA: toMap(test.overrides), changes: changes}
+
+			if err := o.Override(context.TODO(), []*CommitSummary{test.commit}); err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if test.commit.EffectiveType != test.expected {
+				t.Errorf("Expected effective type: %s, but got: %s", test.expected, test.commit.EffectiveType)
+			}
+
+			switch {
+			case test.matched < 0 && test.commit.Override != nil:
+				t.Errorf("Expected no rule to match, but got: %s", test.commit.Override.String())
+			case test.matched >= 0 && test.commit.Override != &test.overrides[test.matched]:
+				t.Errorf("Expected rule[%d] to match, but got: %v", test.matched, test.commit.Override)
+			}
+		})
+	}
+}
+
+func TestWriteOverrides(t *testing.T) {
+	overrides := []Override{
+		{SHA: "c7d14787027", Do: "drop", Comments: []string{"UPSTREAM: <carry>: /readyz update stacktrace", "migrated from v1.24: 828d775b1f5"}},
+		{Message: `hack/update-vendor\.sh`, Paths: []string{"vendor/**", "go.sum"}, Do: "drop", Reason: "we regenerate vendor"},
+		{Type: "drop", Message: `generated files`, Do: "regenerate", Commands: []string{"make update", `hack/update-codegen.sh "k8s.io/api"`}},
+	}
+
+	b := &strings.Builder{}
+	if err := WriteOverrides(b, overrides); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	fpath := filepath.Join(t.TempDir(), "overrides.yaml")
+	if err := os.WriteFile(fpath, []byte(b.String()), 0644); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	got, err := LoadOverrides(fpath)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	for i := range got {
+		// the rules are compiled when they are read
+		got[i].index, got[i].message = 0, nil
+	}
+	if !reflect.DeepEqual(overrides, got) {
+		t.Errorf("Expected overrides to match: %s", cmp.Diff(overrides, got, cmp.AllowUnexported(Override{})))
+	}
+}
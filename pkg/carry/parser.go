@@ -52,6 +52,15 @@ func parse(s string) (*CommitSummary, error) {
 		return nil, fmt.Errorf("malformed commit log, unknown commit type: %w", err)
 	}
 
+	// a qualified reference points to a PR in a repository
+	// other than kubernetes/kubernetes, ie. owner/repo#1234
+	if repo, number, ok := qualified(commitType); ok {
+		summary.UpstreamRepo = repo
+		if len(summary.UpstreamPR) == 0 {
+			summary.UpstreamPR = fmt.Sprintf("https://github.com/%s/pull/%s", repo, number)
+		}
+	}
+
 	// both effective and original type are equal when
 	// a summary object is initialized.
 	summary.EffectiveType = commitType
@@ -69,6 +78,12 @@ func sanitize(t string) (string, error) {
 
 	switch {
 	case t == "revert" || t == "drop" || t == "carry":
+	case strings.Contains(t, "#"):
+		repo, number, ok := qualified(t)
+		if !ok {
+			return "", fmt.Errorf("unknown type: %s", t)
+		}
+		t = repo + "#" + number
 	default:
 		if _, err := strconv.Atoi(t); err != nil {
 			return "", fmt.Errorf("unknown type: %s", t)
@@ -77,3 +92,30 @@ func sanitize(t string) (string, error) {
 
 	return t, nil
 }
+
+// qualified parses a qualified upstream reference, the repository is
+// either a GitHub owner/repo, or the import path of a kubernetes or
+// kubernetes-sigs repository, ie. k8s.io/kube-openapi#123.
+func qualified(t string) (string, string, bool) {
+	split := strings.SplitN(t, "#", 2)
+	if len(split) != 2 {
+		return "", "", false
+	}
+	if number, err := strconv.Atoi(split[1]); err != nil || number <= 0 {
+		return "", "", false
+	}
+
+	repo := strings.TrimPrefix(split[0], "github.com/")
+	switch {
+	case strings.HasPrefix(repo, "k8s.io/"):
+		repo = "kubernetes/" + strings.TrimPrefix(repo, "k8s.io/")
+	case strings.HasPrefix(repo, "sigs.k8s.io/"):
+		repo = "kubernetes-sigs/" + strings.TrimPrefix(repo, "sigs.k8s.io/")
+	}
+
+	owner := strings.Split(repo, "/")
+	if len(owner) != 2 || len(owner[0]) == 0 || len(owner[1]) == 0 {
+		return "", "", false
+	}
+	return repo, split[1], true
+}
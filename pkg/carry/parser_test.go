@@ -22,6 +22,7 @@ func TestParse(t *testing.T) {
 			expected: &CommitSummary{
 				SHA:               "d032c6e6463",
 				EffectiveType:     "revert",
+				OriginalType:      "revert",
 				Message:           "<carry>: Unskip OCP SDN related tests",
 				MessageWithPrefix: "UPSTREAM: revert: <carry>: Unskip OCP SDN related tests",
 				OpenShiftCommit:   "https://github.com/openshift/kubernetes/commit/d032c6e6463?w=1",
@@ -35,6 +36,7 @@ func TestParse(t *testing.T) {
 			expected: &CommitSummary{
 				SHA:               "db4c4bbd6d6",
 				EffectiveType:     "107900",
+				OriginalType:      "107900",
 				Message:           "Add an e2e test for updating a static pod while it restarts",
 				MessageWithPrefix: "UPSTREAM: 107900: Add an e2e test for updating a static pod while it restarts",
 				OpenShiftCommit:   "https://github.com/openshift/kubernetes/commit/db4c4bbd6d6?w=1",
@@ -48,6 +50,7 @@ func TestParse(t *testing.T) {
 			expected: &CommitSummary{
 				SHA:               "d7b268fffba",
 				EffectiveType:     "carry",
+				OriginalType:      "carry",
 				Message:           "use console-public config map for console redirect",
 				MessageWithPrefix: "UPSTREAM: <carry>: use console-public config map for console redirect",
 				OpenShiftCommit:   "https://github.com/openshift/kubernetes/commit/d7b268fffba?w=1",
@@ -61,12 +64,48 @@ func TestParse(t *testing.T) {
 			expected: &CommitSummary{
 				SHA:               "c77caa826a0",
 				EffectiveType:     "drop",
+				OriginalType:      "drop",
 				Message:           "update vendor files",
 				MessageWithPrefix: "UPSTREAM: <drop>: update vendor files",
 				OpenShiftCommit:   "https://github.com/openshift/kubernetes/commit/c77caa826a0?w=1",
 				UpstreamPR:        "",
 			},
 		},
+		{
+			name:             "qualified pick",
+			input:            "\t4bc1c0f0c3a\t\t\tUPSTREAM: kubernetes-sigs/structured-merge-diff#170: Fix nil pointer in merge\thttps://github.com/openshift/kubernetes/commit/4bc1c0f0c3a?w=1",
+			errShouldContain: "",
+			expected: &CommitSummary{
+				SHA:               "4bc1c0f0c3a",
+				EffectiveType:     "kubernetes-sigs/structured-merge-diff#170",
+				OriginalType:      "kubernetes-sigs/structured-merge-diff#170",
+				Message:           "Fix nil pointer in merge",
+				MessageWithPrefix: "UPSTREAM: kubernetes-sigs/structured-merge-diff#170: Fix nil pointer in merge",
+				OpenShiftCommit:   "https://github.com/openshift/kubernetes/commit/4bc1c0f0c3a?w=1",
+				UpstreamPR:        "https://github.com/kubernetes-sigs/structured-merge-diff/pull/170",
+				UpstreamRepo:      "kubernetes-sigs/structured-merge-diff",
+			},
+		},
+		{
+			name:             "qualified pick with import path",
+			input:            "\t4bc1c0f0c3a\t\t\tUPSTREAM: k8s.io/kube-openapi#291: Fix schema\thttps://github.com/openshift/kubernetes/commit/4bc1c0f0c3a?w=1",
+			errShouldContain: "",
+			expected: &CommitSummary{
+				SHA:               "4bc1c0f0c3a",
+				EffectiveType:     "kubernetes/kube-openapi#291",
+				OriginalType:      "kubernetes/kube-openapi#291",
+				Message:           "Fix schema",
+				MessageWithPrefix: "UPSTREAM: k8s.io/kube-openapi#291: Fix schema",
+				OpenShiftCommit:   "https://github.com/openshift/kubernetes/commit/4bc1c0f0c3a?w=1",
+				UpstreamPR:        "https://github.com/kubernetes/kube-openapi/pull/291",
+				UpstreamRepo:      "kubernetes/kube-openapi",
+			},
+		},
+		{
+			name:             "malformed qualified pick",
+			input:            "\t4bc1c0f0c3a\t\t\tUPSTREAM: structured-merge-diff#170: Fix nil pointer in merge\thttps://github.com/openshift/kubernetes/commit/4bc1c0f0c3a?w=1",
+			errShouldContain: "unknown type",
+		},
 	}
 
 	for _, test := range tests {
@@ -95,3 +134,31 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestLogLine(t *testing.T) {
+	tests := []struct {
+		subject  string
+		expected string
+	}{
+		{
+			subject:  "UPSTREAM: <carry>: filter out CustomResourceQuota paths from OpenAPI",
+			expected: "\tc6840e84f86\t\t\tUPSTREAM: <carry>: filter out CustomResourceQuota paths from OpenAPI\thttps://github.com/openshift/kubernetes/commit/c6840e84f86?w=1",
+		},
+		{
+			subject:  "UPSTREAM: 93286: wait for apiservices on startup",
+			expected: "\tc6840e84f86\t\t\tUPSTREAM: 93286: wait for apiservices on startup\thttps://github.com/openshift/kubernetes/commit/c6840e84f86?w=1\thttps://github.com/kubernetes/kubernetes/pull/93286",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.subject, func(t *testing.T) {
+			got, err := LogLine("c6840e84f86", test.subject)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if got != test.expected {
+				t.Errorf("Expected: %q, but got: %q", test.expected, got)
+			}
+		})
+	}
+}
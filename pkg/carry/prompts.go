@@ -1,23 +1,22 @@
 package carry
 
-import (
-	"fmt"
-	"os"
-
-	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
-)
-
 type Prompt interface {
-	ShouldDrop(dropSHA string) bool
+	ShouldDrop(commit *CommitSummary) bool
 }
 
 type prompt struct {
 	answers []Override
 }
 
-func (p *prompt) ShouldDrop(dropSHA string) bool {
+func (p *prompt) ShouldDrop(commit *CommitSummary) bool {
+	// the override rule that matched while the carry commits were read
+	// takes care of both exact SHA and pattern rules.
+	if commit.Override != nil {
+		return commit.Override.Do == "drop"
+	}
+
 	for _, override := range p.answers {
-		if override.SHA == dropSHA && override.Do == "drop" {
+		if commit.HasSHA(override.SHA) && override.Do == "drop" {
 			return true
 		}
 	}
@@ -25,30 +24,10 @@ func (p *prompt) ShouldDrop(dropSHA string) bool {
 }
 
 func NewPromptsFromFile(fpath string) (Prompt, error) {
-	stat, err := os.Stat(fpath)
+	overrides, err := readOverrides(fpath)
 	if err != nil {
-		return nil, fmt.Errorf("error loading file %q - %w", fpath, err)
-	}
-
-	if stat.IsDir() {
-		return nil, fmt.Errorf("is a directory %q", err)
-	}
-
-	file, err := os.Open(fpath)
-	if err != nil {
-		return nil, fmt.Errorf("error loading file %q - %w", fpath, err)
-	}
-
-	defer file.Close()
-
-	decoder := utilyaml.NewYAMLToJSONDecoder(file)
-	o := &struct {
-		Overrides []Override `json:"overrides,omitempty"`
-	}{}
-
-	if err := decoder.Decode(o); err != nil {
-		return nil, fmt.Errorf("failed to decode prompts from %q - %w", fpath, err)
+		return nil, err
 	}
 
-	return &prompt{answers: o.Overrides}, nil
+	return &prompt{answers: overrides}, nil
 }
@@ -2,28 +2,58 @@ package carry
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
 type CommitSummary struct {
-	SHA                         string
+	// SHA is the full object ID of the commit once it is resolved,
+	// AbbreviatedSHA is the SHA as it appears in the carry commit log.
+	SHA, AbbreviatedSHA         string
 	EffectiveType, OriginalType string
 	Message                     string
 	MessageWithPrefix           string
 	OpenShiftCommit             string
 	UpstreamPR                  string
+
+	// UpstreamRepo is the owner/repo of the upstream PR, it is set
+	// only when the PR is not in kubernetes/kubernetes
+	UpstreamRepo string
+
+	// Override is the rule that matched this commit, nil if none
+	Override *Override
+
+	// Unit is the multi-commit upstream PR this commit belongs to,
+	// nil if the commit is applied on its own
+	Unit *Unit
 }
 
 func (r *CommitSummary) String() string {
-	return fmt.Sprintf("%s(%s): %s - %s", r.SHA, r.EffectiveType, r.Message, r.OpenShiftCommit)
+	return fmt.Sprintf("%s(%s): %s - %s", r.ShortSHA(), r.EffectiveType, r.Message, r.OpenShiftCommit)
+}
+
+// ShortSHA returns the SHA as it appears in the carry commit log.
+func (r *CommitSummary) ShortSHA() string {
+	if len(r.AbbreviatedSHA) > 0 {
+		return r.AbbreviatedSHA
+	}
+	return r.SHA
+}
+
+// HasSHA returns true if the given SHA refers to this commit, either
+// the full object ID or the abbreviated SHA from the carry commit log.
+func (r *CommitSummary) HasSHA(sha string) bool {
+	return len(sha) > 0 && (sha == r.SHA || sha == r.AbbreviatedSHA)
 }
 
 type csvReader struct {
 	fpath string
 }
 
-func (r *csvReader) Read() ([]*CommitSummary, error) {
+func (r *csvReader) Read(_ context.Context) ([]*CommitSummary, error) {
 	file, err := os.Open(r.fpath)
 	if err != nil {
 		return nil, fmt.Errorf("error loading file %q - %w", r.fpath, err)
@@ -33,8 +63,13 @@ func (r *csvReader) Read() ([]*CommitSummary, error) {
 
 	scanner := bufio.NewScanner(file)
 	records := make([]*CommitSummary, 0)
-	// we assume first line is not the header
+	// we assume first line is not the header, a line that starts
+	// with '#' is a comment, ie. the openshift commit the log was
+	// generated from.
 	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
 		record, err := parse(scanner.Text())
 		if err != nil {
 			return nil, fmt.Errorf("parsing failed: %w", err)
@@ -44,3 +79,31 @@ func (r *csvReader) Read() ([]*CommitSummary, error) {
 
 	return records, nil
 }
+
+// LogLine returns the line of the carry commit log for the given
+// openshift commit, in the format generate-carries.sh writes it.
+func LogLine(sha, subject string) (string, error) {
+	summary, err := Summarize(sha, subject)
+	if err != nil {
+		return "", err
+	}
+	line := fmt.Sprintf("\t%s\t\t\t%s\t%s", sha, subject, summary.OpenShiftCommit)
+	if len(summary.UpstreamPR) > 0 && len(summary.UpstreamRepo) == 0 {
+		line = fmt.Sprintf("%s\t%s", line, summary.UpstreamPR)
+	}
+	return line, nil
+}
+
+// Summarize returns the summary of the given openshift commit as the
+// carry commit log would have it, with the links to the openshift
+// commit, and to the upstream PR if the subject refers to one.
+func Summarize(sha, subject string) (*CommitSummary, error) {
+	summary, err := parse(fmt.Sprintf("\t%s\t\t\t%s\thttps://github.com/openshift/kubernetes/commit/%s?w=1", sha, subject, sha))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := strconv.Atoi(summary.OriginalType); err == nil {
+		summary.UpstreamPR = fmt.Sprintf("https://github.com/kubernetes/kubernetes/pull/%s", summary.OriginalType)
+	}
+	return summary, nil
+}
@@ -0,0 +1,144 @@
+package carry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// MessageReader returns the full commit message of a given commit.
+type MessageReader interface {
+	CommitMessage(ctx context.Context, sha string) (string, error)
+}
+
+// RevertPair is a carry commit along with a later revert of it, both
+// are in the carry commit log, so picking them has no net effect.
+type RevertPair struct {
+	Original, Revert *CommitSummary
+}
+
+func (p RevertPair) String() string {
+	return fmt.Sprintf("%s cancels %s", p.Revert.String(), p.Original.String())
+}
+
+// cancelReverts links each revert commit to the carry commit it reverts,
+// if both are in the list, the pair is removed from the list.
+// A revert is linked either by the 'This reverts commit X' line in its
+// commit message, or by a subject that matches the subject of the
+// original carry commit.
+// A commit matched by an exact SHA override is never cancelled, the
+// override decides what happens to it, as it does for any other commit.
+func cancelReverts(ctx context.Context, commits []*CommitSummary, messages MessageReader, resolver Resolver) ([]*CommitSummary, []RevertPair, error) {
+	pairs := make([]RevertPair, 0)
+	cancelled := map[*CommitSummary]bool{}
+
+	for i := range commits {
+		revert := commits[i]
+		if revert.OriginalType != "revert" {
+			continue
+		}
+		if hasSHAOverride(revert) {
+			klog.Infof("revert: not cancelled, overridden by %s - %s", revert.Override.String(), revert.String())
+			continue
+		}
+
+		reverted, err := revertedSHA(ctx, revert, messages, resolver)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the original must be picked before its revert
+		var original *CommitSummary
+		for j := i - 1; j >= 0; j-- {
+			candidate := commits[j]
+			if cancelled[candidate] {
+				continue
+			}
+			if candidate.HasSHA(reverted) || "UPSTREAM: "+revert.Message == candidate.MessageWithPrefix {
+				original = candidate
+				break
+			}
+		}
+		if original == nil {
+			klog.Infof("revert: original not in carry commit log - %s", revert.String())
+			continue
+		}
+		if hasSHAOverride(original) {
+			klog.Infof("revert: not cancelled, the original is overridden by %s - %s", original.Override.String(), revert.String())
+			continue
+		}
+
+		cancelled[original], cancelled[revert] = true, true
+		pairs = append(pairs, RevertPair{Original: original, Revert: revert})
+	}
+
+	if len(pairs) == 0 {
+		return commits, pairs, nil
+	}
+
+	remaining := make([]*CommitSummary, 0, len(commits)-2*len(pairs))
+	for i := range commits {
+		if !cancelled[commits[i]] {
+			remaining = append(remaining, commits[i])
+		}
+	}
+
+	klog.Infof("revert: %d pair(s) cancel each other out, they will not be picked", len(pairs))
+	return remaining, pairs, nil
+}
+
+func hasSHAOverride(commit *CommitSummary) bool {
+	return commit.Override != nil && len(commit.Override.SHA) > 0
+}
+
+// PrintReverts prints the pairs of carry commits the reader left out, as
+// a revert later in the carry commit log cancels the original.
+func PrintReverts(reader CommitReader) {
+	r, ok := reader.(RevertReader)
+	if !ok || len(r.Reverts()) == 0 {
+		return
+	}
+
+	b := &strings.Builder{}
+	for i, pair := range r.Reverts() {
+		fmt.Fprintf(b, "%d. %s\n", i+1, pair.Original.MessageWithPrefix)
+		fmt.Fprintf(b, "   original: %s %s\n", pair.Original.ShortSHA(), pair.Original.OpenShiftCommit)
+		fmt.Fprintf(b, "   revert: %s %s\n", pair.Revert.ShortSHA(), pair.Revert.OpenShiftCommit)
+	}
+	klog.Infof("cancelled reverts: pairs(%d), not picked\n%s", len(r.Reverts()), b.String())
+}
+
+func revertedSHA(ctx context.Context, revert *CommitSummary, messages MessageReader, resolver Resolver) (string, error) {
+	if messages == nil {
+		return "", nil
+	}
+
+	msg, err := messages.CommitMessage(ctx, revert.SHA)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit message of %s - %w", revert.String(), err)
+	}
+
+	const prefix = "This reverts commit "
+	scanner := bufio.NewScanner(strings.NewReader(msg))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		sha := strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, prefix)), ".")
+		if resolver == nil {
+			return sha, nil
+		}
+		// the reverted commit may be abbreviated, or may not exist
+		// in this repository, in which case we match by subject.
+		if full, err := resolver.ResolveSHA(ctx, sha); err == nil {
+			return full, nil
+		}
+		return sha, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,115 @@
+package carry
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeMessageReader map[string]string
+
+func (f fakeMessageReader) CommitMessage(_ context.Context, sha string) (string, error) {
+	return f[sha], nil
+}
+
+func TestCancelReverts(t *testing.T) {
+	tests := []struct {
+		name      string
+		commits   []*CommitSummary
+		messages  fakeMessageReader
+		remaining []string
+		pairs     int
+	}{
+		{
+			name: "linked by commit message",
+			commits: []*CommitSummary{
+				{SHA: "d7b268fffba", OriginalType: "carry", MessageWithPrefix: "UPSTREAM: <carry>: use console-public config map"},
+				{SHA: "c77caa826a0", OriginalType: "drop", MessageWithPrefix: "UPSTREAM: <drop>: update vendor files"},
+				{SHA: "d032c6e6463", OriginalType: "revert", Message: "<carry>: use console-public", MessageWithPrefix: "UPSTREAM: revert: <carry>: use console-public"},
+			},
+			messages: fakeMessageReader{
+				"d032c6e6463": "UPSTREAM: revert: <carry>: use console-public\n\nThis reverts commit d7b268fffba.\n",
+			},
+			remaining: []string{"c77caa826a0"},
+			pairs:     1,
+		},
+		{
+			name: "linked by subject",
+			commits: []*CommitSummary{
+				{SHA: "8bd488b66eb", OriginalType: "carry", MessageWithPrefix: "UPSTREAM: <carry>: Unskip OCP SDN related tests"},
+				{SHA: "d032c6e6463", OriginalType: "revert", Message: "<carry>: Unskip OCP SDN related tests", MessageWithPrefix: "UPSTREAM: revert: <carry>: Unskip OCP SDN related tests"},
+			},
+			messages:  fakeMessageReader{},
+			remaining: []string{},
+			pairs:     1,
+		},
+		{
+			name: "an sha override on the revert wins over the pair",
+			commits: []*CommitSummary{
+				{SHA: "d7b268fffba", OriginalType: "carry", MessageWithPrefix: "UPSTREAM: <carry>: use console-public config map"},
+				{SHA: "d032c6e6463", OriginalType: "revert", EffectiveType: "carry", Override: &Override{SHA: "d032c6e6463", Do: "carry"}, Message: "<carry>: use console-public", MessageWithPrefix: "UPSTREAM: revert: <carry>: use console-public"},
+			},
+			messages: fakeMessageReader{
+				"d032c6e6463": "UPSTREAM: revert: <carry>: use console-public\n\nThis reverts commit d7b268fffba.\n",
+			},
+			remaining: []string{"d7b268fffba", "d032c6e6463"},
+			pairs:     0,
+		},
+		{
+			name: "an sha override on the original wins over the pair",
+			commits: []*CommitSummary{
+				{SHA: "8bd488b66eb", OriginalType: "carry", EffectiveType: "drop", Override: &Override{SHA: "8bd488b66eb", Do: "drop"}, MessageWithPrefix: "UPSTREAM: <carry>: Unskip OCP SDN related tests"},
+				{SHA: "d032c6e6463", OriginalType: "revert", Message: "<carry>: Unskip OCP SDN related tests", MessageWithPrefix: "UPSTREAM: revert: <carry>: Unskip OCP SDN related tests"},
+			},
+			messages:  fakeMessageReader{},
+			remaining: []string{"8bd488b66eb", "d032c6e6463"},
+			pairs:     0,
+		},
+		{
+			name: "a pattern override does not prevent the pair",
+			commits: []*CommitSummary{
+				{SHA: "8bd488b66eb", OriginalType: "carry", EffectiveType: "drop", Override: &Override{Type: "carry", Do: "drop"}, MessageWithPrefix: "UPSTREAM: <carry>: Unskip OCP SDN related tests"},
+				{SHA: "d032c6e6463", OriginalType: "revert", Message: "<carry>: Unskip OCP SDN related tests", MessageWithPrefix: "UPSTREAM: revert: <carry>: Unskip OCP SDN related tests"},
+			},
+			messages:  fakeMessageReader{},
+			remaining: []string{},
+			pairs:     1,
+		},
+		{
+			name: "original not in the list",
+			commits: []*CommitSummary{
+				{SHA: "c77caa826a0", OriginalType: "drop", MessageWithPrefix: "UPSTREAM: <drop>: update vendor files"},
+				{SHA: "d032c6e6463", OriginalType: "revert", Message: "<carry>: Unskip OCP SDN related tests", MessageWithPrefix: "UPSTREAM: revert: <carry>: Unskip OCP SDN related tests"},
+			},
+			messages: fakeMessageReader{
+				"d032c6e6463": "UPSTREAM: revert: <carry>: Unskip OCP SDN related tests\n\nThis reverts commit 8bd488b66eb.\n",
+			},
+			remaining: []string{"c77caa826a0", "d032c6e6463"},
+			pairs:     0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			remaining, pairs, err := cancelReverts(context.TODO(), test.commits, test.messages, nil)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+
+			if len(pairs) != test.pairs {
+				t.Errorf("Expected %d cancelled pair(s), but got: %d", test.pairs, len(pairs))
+			}
+			got := make([]string, 0)
+			for _, commit := range remaining {
+				got = append(got, commit.SHA)
+			}
+			if len(got) != len(test.remaining) {
+				t.Fatalf("Expected remaining commits: %v, but got: %v", test.remaining, got)
+			}
+			for i := range got {
+				if got[i] != test.remaining[i] {
+					t.Errorf("Expected remaining commits: %v, but got: %v", test.remaining, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package carry
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/klog/v2"
+)
+
+// Unit is a group of consecutive carry commits that pick the same
+// upstream PR, they are applied, or skipped, together.
+type Unit struct {
+	UpstreamPR string
+	Commits    []*CommitSummary
+}
+
+func (u *Unit) String() string {
+	return fmt.Sprintf("upstream PR(%s) with %d commits", u.UpstreamPR, len(u.Commits))
+}
+
+// First returns true if the given commit is the first commit of the unit.
+func (u *Unit) First(commit *CommitSummary) bool {
+	return len(u.Commits) > 0 && u.Commits[0] == commit
+}
+
+// groupUnits groups consecutive commits that pick the same upstream PR
+// into a unit. A commit whose type has been overridden is not part of
+// a unit.
+func groupUnits(commits []*CommitSummary) []*Unit {
+	units := make([]*Unit, 0)
+	for i := 0; i < len(commits); {
+		j := i + 1
+		if isUpstreamPick(commits[i]) {
+			for j < len(commits) && isUpstreamPick(commits[j]) && commits[j].EffectiveType == commits[i].EffectiveType {
+				j++
+			}
+		}
+
+		if j-i > 1 {
+			unit := &Unit{UpstreamPR: commits[i].UpstreamPR, Commits: commits[i:j:j]}
+			for _, commit := range unit.Commits {
+				commit.Unit = unit
+			}
+			units = append(units, unit)
+			klog.Infof("unit: %s", unit.String())
+		}
+		i = j
+	}
+	return units
+}
+
+func isUpstreamPick(commit *CommitSummary) bool {
+	if commit.EffectiveType != commit.OriginalType {
+		return false
+	}
+	if _, _, ok := qualified(commit.EffectiveType); ok {
+		return true
+	}
+	_, err := strconv.Atoi(commit.EffectiveType)
+	return err == nil
+}
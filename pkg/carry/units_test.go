@@ -0,0 +1,43 @@
+package carry
+
+import (
+	"testing"
+)
+
+func TestGroupUnits(t *testing.T) {
+	commits := []*CommitSummary{
+		{SHA: "1", EffectiveType: "108366", OriginalType: "108366", UpstreamPR: "https://github.com/kubernetes/kubernetes/pull/108366"},
+		{SHA: "2", EffectiveType: "108366", OriginalType: "108366", UpstreamPR: "https://github.com/kubernetes/kubernetes/pull/108366"},
+		{SHA: "3", EffectiveType: "carry", OriginalType: "carry"},
+		{SHA: "4", EffectiveType: "107900", OriginalType: "107900", UpstreamPR: "https://github.com/kubernetes/kubernetes/pull/107900"},
+		{SHA: "5", EffectiveType: "108366", OriginalType: "108366", UpstreamPR: "https://github.com/kubernetes/kubernetes/pull/108366"},
+		{SHA: "6", EffectiveType: "108366", OriginalType: "108366", UpstreamPR: "https://github.com/kubernetes/kubernetes/pull/108366"},
+		{SHA: "7", EffectiveType: "drop", OriginalType: "108366", UpstreamPR: "https://github.com/kubernetes/kubernetes/pull/108366"},
+	}
+
+	units := groupUnits(commits)
+	if len(units) != 2 {
+		t.Fatalf("Expected 2 units, but got: %d", len(units))
+	}
+
+	for i, expected := range [][]string{{"1", "2"}, {"5", "6"}} {
+		got := units[i].Commits
+		if len(got) != len(expected) {
+			t.Fatalf("Expected unit[%d] to have %d commits, but got: %d", i, len(expected), len(got))
+		}
+		for j := range got {
+			if got[j].SHA != expected[j] || got[j].Unit != units[i] {
+				t.Errorf("Expected commit %s in unit[%d], but got: %s", expected[j], i, got[j].SHA)
+			}
+		}
+		if !units[i].First(got[0]) || units[i].First(got[1]) {
+			t.Errorf("Expected commit %s to be the first of unit[%d]", expected[0], i)
+		}
+	}
+
+	for _, commit := range []*CommitSummary{commits[2], commits[3], commits[6]} {
+		if commit.Unit != nil {
+			t.Errorf("Expected commit %s not to be part of a unit", commit.SHA)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package carry
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// readComments returns the comment lines that precede each item of the
+// top level override list, in the order the items appear in the file.
+func readComments(r io.Reader) ([][]string, error) {
+	comments := make([][]string, 0)
+	var pending []string
+	indent := -1
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			pending = append(pending, strings.TrimSpace(strings.TrimPrefix(trimmed, "#")))
+		case strings.HasPrefix(trimmed, "- "):
+			current := len(line) - len(strings.TrimLeft(line, " "))
+			if indent < 0 {
+				indent = current
+			}
+			if current != indent {
+				continue
+			}
+			comments = append(comments, pending)
+			pending = nil
+		}
+	}
+
+	return comments, scanner.Err()
+}
+
+// WriteOverrides writes the given rules in the format of an override
+// file, the comments of each rule are written right above it.
+func WriteOverrides(w io.Writer, overrides []Override) error {
+	b := &strings.Builder{}
+	b.WriteString("overrides:\n")
+	for i := range overrides {
+		o := &overrides[i]
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, comment := range o.Comments {
+			b.WriteString(strings.TrimSpace("# "+comment) + "\n")
+		}
+
+		prefix := "- "
+		field := func(key, value string) {
+			if len(value) == 0 {
+				b.WriteString(fmt.Sprintf("%s%s:\n", prefix, key))
+			} else {
+				b.WriteString(fmt.Sprintf("%s%s: %s\n", prefix, key, value))
+			}
+			prefix = "  "
+		}
+		if len(o.SHA) > 0 {
+			field("sha", o.SHA)
+		}
+		if len(o.Message) > 0 {
+			field("message", strconv.Quote(o.Message))
+		}
+		if len(o.Paths) > 0 {
+			field("paths", "")
+			for _, glob := range o.Paths {
+				b.WriteString(fmt.Sprintf("  - %s\n", strconv.Quote(glob)))
+			}
+		}
+		if len(o.Type) > 0 {
+			field("type", o.Type)
+		}
+		field("do", o.Do)
+		if len(o.Commands) > 0 {
+			field("commands", "")
+			for _, command := range o.Commands {
+				b.WriteString(fmt.Sprintf("  - %s\n", strconv.Quote(command)))
+			}
+		}
+		if len(o.Reason) > 0 {
+			field("reason", strconv.Quote(o.Reason))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
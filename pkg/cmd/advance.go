@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/advance"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type AdvanceOptions struct {
+	Target string
+	To     string
+	Source string
+	Branch string
+}
+
+func NewAdvanceCommand() *cobra.Command {
+	options := &AdvanceOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "advance --target=v1.24.0-rc.0 --to=v1.24.0",
+		Short:        "Moves the current rebase branch to a newer upstream tag, the carries are copied to a new rebase branch along with their resolutions.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
+			from, _ := target.Parse(options.Target)
+			to, _ := target.Parse(options.To)
+
+			var runner Runner
+			var err error
+			if runner, err = advance.New(ctx, from, to, options.Source, options.Branch); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "advance failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Target, "target", options.Target, "rebase target of the current rebase branch, ie. v1.24.0-rc.0")
+	cmd.Flags().StringVar(&options.To, "to", options.To, "upstream tag to advance to, ie. v1.24.0, it is the rebase target of the new branch")
+	cmd.Flags().StringVar(&options.Source, "source", options.Source, "the current rebase branch, defaults to the checked out branch")
+	cmd.Flags().StringVar(&options.Branch, "branch", options.Branch, "name of the new rebase branch, defaults to rebase-{to}")
+	return cmd
+}
+
+func (o *AdvanceOptions) Validate() error {
+	if _, err := target.Parse(o.Target); err != nil {
+		return fmt.Errorf("--target - %w", err)
+	}
+	if t, err := target.Parse(o.To); err != nil || t.IsLine() {
+		return fmt.Errorf("--to must be an upstream tag ie. v1.24.0")
+	}
+	return nil
+}
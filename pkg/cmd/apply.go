@@ -1,33 +1,56 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"k8s.io/klog/v2"
 
 	flag "github.com/spf13/pflag"
 	"github.com/tkashem/rebase/pkg/apply"
 	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"github.com/tkashem/rebase/pkg/workspace"
 )
 
 type ApplyOptions struct {
 	Options
 	CherryPickFromSHA string
+	Base              string
+	KeepGoing         bool
+	Continue, Abort   bool
+	CheckpointEvery   int
+	Hooks             apply.Hooks
+	KeepEmpty         bool
 }
 
 func NewApplyCommand() *cobra.Command {
-	options := &ApplyOptions{}
+	options := &ApplyOptions{CheckpointEvery: 10, Hooks: apply.Hooks{Policy: apply.HookStop}}
 
 	cmd := &cobra.Command{
-		Use:          "apply --target=v.1.24 --carry-commit-file={carry-commit-log-file-path} --overrides={override file path}",
+		Use:          "apply --target=v1.24 --carry-commit-file={carry-commit-log-file-path} --overrides={override file path}",
 		Short:        "Iterates through the specified commit log file and applies each commit.",
 		Example:      "",
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
 			if err := options.Validate(); err != nil {
 				return err
 			}
 
-			reader, err := carry.NewReaderFromFile(options.CarryCommitLogFilePath, options.OverrideFilePath)
+			t, _ := target.Parse(options.Target)
+			repository, err := git.OpenWorkingDir()
+			if err != nil {
+				return err
+			}
+			if options.Base == "auto" {
+				if _, options.Base, err = target.Resolve(ctx, t, repository); err != nil {
+					return err
+				}
+				klog.InfoS("derived the base of the target", "target", options.Target, "base", options.Base)
+			}
+			reader, err := carry.NewReaderFromFile(ctx, options.CarryCommitLogFilePath, options.OverrideFilePath, repository)
 			if err != nil {
 				return err
 			}
@@ -37,11 +60,11 @@ func NewApplyCommand() *cobra.Command {
 			}
 
 			var runner Runner
-			if runner, err = apply.New(reader, override, options.Target, options.CherryPickFromSHA); err != nil {
+			if runner, err = apply.New(ctx, reader, override, t, options.CherryPickFromSHA, options.Base, options.KeepGoing, options.Mode(), options.CheckpointEvery, options.Hooks, options.KeepEmpty); err != nil {
 				return err
 			}
 
-			if err := runner.Run(); err != nil {
+			if err := runner.Run(ctx); err != nil {
 				klog.ErrorS(err, "apply failed")
 				return err
 			}
@@ -52,5 +75,43 @@ func NewApplyCommand() *cobra.Command {
 
 	options.AddFlags(cmd.Flags())
 	flag.StringVar(&options.CherryPickFromSHA, "cherry-pick-from", options.CherryPickFromSHA, "SHA pointing to the HEAD of the branch from where to pick commits with merge conflicts")
+	cmd.Flags().StringVar(&options.Base, "base", options.Base, "upstream tag of the previous rebase, ie. v1.23.0, or auto to derive it from the target, enables rename-aware picks")
+	cmd.Flags().BoolVar(&options.KeepGoing, "keep-going", options.KeepGoing, "skip a carry that conflicts, and print the inventory of all conflicts at the end")
+	cmd.Flags().BoolVar(&options.Continue, "continue", options.Continue, "complete the pick that stopped on a conflict once it is resolved, and apply the rest")
+	cmd.Flags().BoolVar(&options.Abort, "abort", options.Abort, "roll back the pick that stopped on a conflict, and stop")
+	cmd.Flags().StringArrayVar(&options.Hooks.Commands, "hook", options.Hooks.Commands, "shell command to run after each carry is picked, {packages} is replaced with the Go packages the carry touched, ie. 'go vet {packages}', repeatable")
+	cmd.Flags().StringVar((*string)(&options.Hooks.Policy), "hook-policy", string(options.Hooks.Policy), "what to do when a hook fails: stop, mark the carry in its commit message, or warn")
+	cmd.Flags().BoolVar(&options.KeepEmpty, "keep-empty", options.KeepEmpty, "commit a carry whose pick is empty, as its change is already upstream, as an empty commit, it is skipped by default")
+	cmd.Flags().IntVar(&options.CheckpointEvery, "checkpoint-every", options.CheckpointEvery, "record a checkpoint ref after every N carries, 0 to disable, see rollback")
 	return cmd
 }
+
+func (o *ApplyOptions) Validate() error {
+	if err := o.Options.Validate(); err != nil {
+		return err
+	}
+	if o.Continue && o.Abort {
+		return fmt.Errorf("--continue and --abort are mutually exclusive")
+	}
+	if err := o.Hooks.Validate(); err != nil {
+		return fmt.Errorf("--hook-policy - %w", err)
+	}
+	if len(o.Base) == 0 || o.Base == "auto" {
+		return nil
+	}
+	if t, err := target.Parse(o.Base); err != nil || t.IsLine() {
+		return fmt.Errorf("--base must be an upstream tag ie. v1.23.0, or auto")
+	}
+	return nil
+}
+
+// Mode returns how apply treats a pick that stopped on a conflict.
+func (o *ApplyOptions) Mode() workspace.Mode {
+	switch {
+	case o.Continue:
+		return workspace.Continue
+	case o.Abort:
+		return workspace.Abort
+	}
+	return workspace.Start
+}
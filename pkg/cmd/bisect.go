@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/bisect"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type BisectOptions struct {
+	Target  string
+	Command string
+}
+
+func NewBisectCommand() *cobra.Command {
+	options := &BisectOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "bisect --target=v1.24 --cmd=\"make WHAT=cmd/kube-apiserver\"",
+		Short:        "Finds the first carry between the rebase marker and HEAD the given command fails at, in a temporary worktree.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
+			t, _ := target.Parse(options.Target)
+
+			var runner Runner
+			var err error
+			if runner, err = bisect.New(t, options.Command); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "bisect failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Target, "target", options.Target, "rebase target, ie. v1.24")
+	cmd.Flags().StringVar(&options.Command, "cmd", options.Command, "shell command that passes at the rebase marker, and fails at HEAD, it runs in the root of the worktree")
+	return cmd
+}
+
+func (o *BisectOptions) Validate() error {
+	if _, err := target.Parse(o.Target); err != nil {
+		return fmt.Errorf("--target - %w", err)
+	}
+	if len(o.Command) == 0 {
+		return fmt.Errorf("--cmd must be specified")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/bump"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type BumpVersionOptions struct {
+	Tag       string
+	Golang    string
+	OpenShift string
+	NoCommit  bool
+}
+
+func NewBumpVersionCommand() *cobra.Command {
+	options := &BumpVersionOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "bump-version --tag=v1.24.0-rc-0 [--golang=1.18] [--openshift=4.11]",
+		Short:        "Updates the kubernetes version label, and the builder image of the Dockerfiles, and commits the change.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if t, err := target.Parse(options.Tag); err != nil || t.IsLine() {
+				return fmt.Errorf("--tag must be an upstream tag ie. v1.24.0")
+			}
+
+			var runner Runner
+			var err error
+			if runner, err = bump.New(options.Tag, options.Golang, options.OpenShift, options.NoCommit); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "bump-version failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Tag, "tag", options.Tag, "upstream tag of the rebase, ie. v1.24.0-rc-0, the label gets the major.minor.patch only")
+	cmd.Flags().StringVar(&options.Golang, "golang", options.Golang, "go version of the builder image, ie. 1.18, the current one is kept if not set")
+	cmd.Flags().StringVar(&options.OpenShift, "openshift", options.OpenShift, "openshift version of the builder image, ie. 4.11, the current one is kept if not set")
+	cmd.Flags().BoolVar(&options.NoCommit, "no-commit", options.NoCommit, "leave the edits in the working tree, ie. for a run-recipe step to commit")
+	return cmd
+}
@@ -1,14 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
+
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
 )
 
 type Runner interface {
-	Run() error
+	Run(ctx context.Context) error
 }
 
 type Options struct {
@@ -21,14 +27,16 @@ func (o *Options) AddFlags(flags *flag.FlagSet) {
 	flags.StringVar(&o.CarryCommitLogFilePath, "carry-commit-file", o.CarryCommitLogFilePath, "file containing all commit logs")
 	flags.StringVar(&o.OverrideFilePath, "overrides", o.OverrideFilePath, "path to file that contains overrides")
 	flags.StringVar(&o.Target, "target", o.Target, "rebase target, ie. v1.24")
+	cobra.MarkFlagFilename(flags, "carry-commit-file")
+	cobra.MarkFlagFilename(flags, "overrides")
 }
 
 func (o *Options) Validate() error {
 	if err := isFile(o.CarryCommitLogFilePath); err != nil {
 		return err
 	}
-	if len(o.Target) == 0 {
-		return fmt.Errorf("must be a valid value ie. v1.24")
+	if _, err := target.Parse(o.Target); err != nil {
+		return fmt.Errorf("--target - %w", err)
 	}
 
 	if len(o.OverrideFilePath) > 0 {
@@ -49,3 +57,55 @@ func isFile(path string) error {
 
 	return nil
 }
+
+type WorktreeOptions struct {
+	Path   string
+	Branch string
+}
+
+func (o *WorktreeOptions) AddFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.Path, "worktree", o.Path, "run in the linked worktree at the given path, it is created if it does not exist, the current checkout is untouched")
+	flags.StringVar(&o.Branch, "worktree-branch", o.Branch, "branch to check out when the worktree is created, it is created at HEAD if it does not exist, defaults to rebase-{target} for a command with --target")
+	cobra.MarkFlagDirname(flags, "worktree")
+}
+
+// Enter makes the worktree the working directory of the command, if
+// one is specified. A file or a directory flag of the command with a
+// relative path is resolved against the current working directory
+// first, so it still refers to the same file. The branch of a new
+// worktree defaults to the rebase branch of the target of the command.
+func (o *WorktreeOptions) Enter(c *cobra.Command) error {
+	if len(o.Path) == 0 {
+		return nil
+	}
+
+	var err error
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		_, isFile := f.Annotations[cobra.BashCompFilenameExt]
+		_, isDir := f.Annotations[cobra.BashCompSubdirsInDir]
+		value := f.Value.String()
+		if err != nil || (!isFile && !isDir) || f.Name == "worktree" || len(value) == 0 || filepath.IsAbs(value) {
+			return
+		}
+		var abs string
+		if abs, err = filepath.Abs(value); err == nil {
+			err = c.Flags().Set(f.Name, abs)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to resolve the paths of the flags - %w", err)
+	}
+
+	// the default applies to a new worktree only, an existing one is
+	// reused with whichever branch it has checked out
+	branch := o.Branch
+	if _, statErr := os.Stat(o.Path); len(branch) == 0 && os.IsNotExist(statErr) {
+		if f := c.Flags().Lookup("target"); f != nil && len(f.Value.String()) > 0 {
+			branch = fmt.Sprintf("rebase-%s", f.Value.String())
+		}
+	}
+	if err := git.EnterWorktree(c.Context(), o.Path, branch); err != nil {
+		return fmt.Errorf("--worktree - %w", err)
+	}
+	return nil
+}
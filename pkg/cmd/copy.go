@@ -1,35 +1,53 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"k8s.io/klog/v2"
 
 	flag "github.com/spf13/pflag"
 	"github.com/tkashem/rebase/pkg/copy"
+	"github.com/tkashem/rebase/pkg/target"
+	"github.com/tkashem/rebase/pkg/workspace"
 )
 
 type CopyOptions struct {
 	Target        string
 	SourceHeadSHA string
 	SourceMarker  string
+
+	Continue, Abort bool
+	CheckpointEvery int
 }
 
 func NewCopyCommand() *cobra.Command {
-	options := &CopyOptions{}
+	options := &CopyOptions{CheckpointEvery: 10}
 
 	cmd := &cobra.Command{
-		Use:          "copy --target=v.1.24 --source={SHA of the head of the source branch}",
+		Use:          "copy --target=v1.24 --source={SHA of the head of the source branch}",
 		Short:        "Iterates through the specified commit log file and applies each commit.",
 		Example:      "",
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
+			t, _ := target.Parse(options.Target)
+			var sourceMarker *target.Target
+			if len(options.SourceMarker) > 0 {
+				sourceMarker, _ = target.Parse(options.SourceMarker)
+			}
+
 			var runner Runner
 			var err error
-			if runner, err = copy.New(options.Target, options.SourceHeadSHA, options.SourceMarker); err != nil {
+			if runner, err = copy.New(ctx, t, options.SourceHeadSHA, sourceMarker, options.Mode(), options.CheckpointEvery); err != nil {
 				return err
 			}
 
-			if err := runner.Run(); err != nil {
+			if err := runner.Run(ctx); err != nil {
 				klog.ErrorS(err, "apply failed")
 				return err
 			}
@@ -41,6 +59,38 @@ func NewCopyCommand() *cobra.Command {
 	flag.StringVar(&options.Target, "target", options.Target, "rebase target, ie. v1.24")
 	flag.StringVar(&options.SourceMarker, "source-marker", options.SourceMarker, "source marker, ie. v1.24.0-rc.1")
 	flag.StringVar(&options.SourceHeadSHA, "source", options.SourceHeadSHA, "SHA pointing to the HEAD of the branch from where to copy")
+	cmd.Flags().BoolVar(&options.Continue, "continue", options.Continue, "complete the copy that stopped on a conflict once it is resolved, and copy the rest")
+	cmd.Flags().BoolVar(&options.Abort, "abort", options.Abort, "roll back the copy that stopped on a conflict, and stop")
+	cmd.Flags().IntVar(&options.CheckpointEvery, "checkpoint-every", options.CheckpointEvery, "record a checkpoint ref after every N commits copied, 0 to disable, see rollback")
 
 	return cmd
 }
+
+func (o *CopyOptions) Validate() error {
+	if _, err := target.Parse(o.Target); err != nil {
+		return fmt.Errorf("--target - %w", err)
+	}
+	if len(o.SourceMarker) > 0 {
+		if _, err := target.Parse(o.SourceMarker); err != nil {
+			return fmt.Errorf("--source-marker - %w", err)
+		}
+	}
+	if o.Continue && o.Abort {
+		return fmt.Errorf("--continue and --abort are mutually exclusive")
+	}
+	if len(o.SourceHeadSHA) == 0 {
+		return fmt.Errorf("--source must be the SHA of the head of the source branch")
+	}
+	return nil
+}
+
+// Mode returns how copy treats a copy that stopped on a conflict.
+func (o *CopyOptions) Mode() workspace.Mode {
+	switch {
+	case o.Continue:
+		return workspace.Continue
+	case o.Abort:
+		return workspace.Abort
+	}
+	return workspace.Start
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/explain"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type ExplainConflictOptions struct {
+	Target string
+	Base   string
+	Open   bool
+}
+
+func NewExplainConflictCommand() *cobra.Command {
+	options := &ExplainConflictOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "explain-conflict --target=v1.24 --base=v1.23.0",
+		Short:        "Shows the upstream commits that touched the conflicting hunks of the carry being cherry-picked.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			t, err := target.Parse(options.Target)
+			if err != nil {
+				return fmt.Errorf("--target - %w", err)
+			}
+			if base, err := target.Parse(options.Base); err != nil || base.IsLine() {
+				return fmt.Errorf("--base must be an upstream tag ie. v1.23.0")
+			}
+
+			repository, err := git.OpenWorkingDir()
+			if err != nil {
+				return err
+			}
+
+			var runner Runner
+			if runner, err = explain.New(repository, t, options.Base, options.Open); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "explain-conflict failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Target, "target", options.Target, "rebase target, ie. v1.24")
+	cmd.Flags().StringVar(&options.Base, "base", options.Base, "upstream tag of the previous rebase, ie. v1.23.0")
+	cmd.Flags().BoolVar(&options.Open, "open", options.Open, "open the openshift commit of the carry in a browser window")
+	return cmd
+}
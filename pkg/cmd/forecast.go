@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/forecast"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type ForecastOptions struct {
+	CarryCommitLogFilePath string
+	OverrideFilePath       string
+	Tag                    string
+}
+
+func NewForecastCommand() *cobra.Command {
+	options := &ForecastOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "forecast --tag=v1.24.0 --carry-commit-file={carry-commit-log-file-path} --overrides={override file path}",
+		Short:        "Forecasts whether each carry commit applies cleanly on top of the target tag, without touching the working tree.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
+			repository, err := git.OpenWorkingDir()
+			if err != nil {
+				return err
+			}
+			reader, err := carry.NewReaderFromFile(ctx, options.CarryCommitLogFilePath, options.OverrideFilePath, repository)
+			if err != nil {
+				return err
+			}
+			tag, err := options.resolve(ctx, repository)
+			if err != nil {
+				return err
+			}
+
+			var runner Runner
+			if runner, err = forecast.New(reader, repository, tag); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "forecast failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.CarryCommitLogFilePath, "carry-commit-file", options.CarryCommitLogFilePath, "file containing all commit logs")
+	cmd.Flags().StringVar(&options.OverrideFilePath, "overrides", options.OverrideFilePath, "path to file that contains overrides")
+	cmd.MarkFlagFilename("carry-commit-file")
+	cmd.MarkFlagFilename("overrides")
+	cmd.Flags().StringVar(&options.Tag, "tag", options.Tag, "upstream tag the carry commits are forecast against, ie. v1.24.0, the latest tag of a release line, ie. v1.24, is used")
+	return cmd
+}
+
+func (o *ForecastOptions) Validate() error {
+	if err := isFile(o.CarryCommitLogFilePath); err != nil {
+		return err
+	}
+	if _, err := target.Parse(o.Tag); err != nil {
+		return fmt.Errorf("--tag - %w", err)
+	}
+	if len(o.OverrideFilePath) > 0 {
+		return isFile(o.OverrideFilePath)
+	}
+	return nil
+}
+
+// resolve returns the tag to forecast against, a release line resolves
+// to its latest upstream tag.
+func (o *ForecastOptions) resolve(ctx context.Context, lister target.TagLister) (string, error) {
+	t, err := target.Parse(o.Tag)
+	if err != nil {
+		return "", err
+	}
+	if !t.IsLine() {
+		return o.Tag, nil
+	}
+
+	tags, err := lister.RemoteTags(ctx, target.Upstream)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the tags of %s - %w", target.Upstream, err)
+	}
+	tag, err := target.Latest(t, tags)
+	if err != nil {
+		return "", err
+	}
+	klog.InfoS("resolved release line to the latest upstream tag", "line", o.Tag, "tag", tag)
+	return tag, nil
+}
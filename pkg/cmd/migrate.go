@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/migrate"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type MigrateOptions struct {
+	From, To               string
+	CarriesDir             string
+	CarryCommitLogFilePath string
+	OverrideFilePath       string
+	Force                  bool
+}
+
+func NewMigrateCommand() *cobra.Command {
+	options := &MigrateOptions{CarriesDir: "carries"}
+
+	cmd := &cobra.Command{
+		Use:          "migrate --from=v1.24 --to=v1.25",
+		Short:        "Carries the overrides of the previous release forward to the carry commits of the next release.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
+			from, _ := target.Parse(options.From)
+			to, _ := target.Parse(options.To)
+			repository, err := git.OpenWorkingDir()
+			if err != nil {
+				return err
+			}
+			reader, err := carry.NewReaderFromFile(ctx, options.CarryCommitLogFilePath, "", repository)
+			if err != nil {
+				return err
+			}
+
+			var runner Runner
+			if runner, err = migrate.New(reader, from, to, options.OverrideFilePath, options.output()); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "migrate failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.From, "from", options.From, "previous rebase target, ie. v1.24")
+	cmd.Flags().StringVar(&options.To, "to", options.To, "next rebase target, ie. v1.25")
+	cmd.Flags().StringVar(&options.CarriesDir, "carries-dir", options.CarriesDir, "directory that contains a folder for each rebase target")
+	cmd.Flags().StringVar(&options.CarryCommitLogFilePath, "carry-commit-file", options.CarryCommitLogFilePath, "carry commit log of the next release, defaults to {carries-dir}/{to}/carry-commits-{to}.log")
+	cmd.Flags().StringVar(&options.OverrideFilePath, "overrides", options.OverrideFilePath, "overrides of the previous release, defaults to {carries-dir}/{from}/overrides.yaml")
+	cmd.MarkFlagDirname("carries-dir")
+	cmd.MarkFlagFilename("carry-commit-file")
+	cmd.MarkFlagFilename("overrides")
+	cmd.Flags().BoolVar(&options.Force, "force", options.Force, "overwrite the overrides of the next release if it exists")
+
+	return cmd
+}
+
+func (o *MigrateOptions) Validate() error {
+	if len(o.From) == 0 || len(o.To) == 0 {
+		return fmt.Errorf("both --from and --to must be a valid value ie. v1.24")
+	}
+	if o.From == o.To {
+		return fmt.Errorf("--from and --to must not be the same: %s", o.From)
+	}
+	if _, err := target.Parse(o.From); err != nil {
+		return fmt.Errorf("--from - %w", err)
+	}
+	if _, err := target.Parse(o.To); err != nil {
+		return fmt.Errorf("--to - %w", err)
+	}
+
+	if len(o.CarryCommitLogFilePath) == 0 {
+		o.CarryCommitLogFilePath = filepath.Join(o.CarriesDir, o.To, fmt.Sprintf("carry-commits-%s.log", o.To))
+	}
+	if err := isFile(o.CarryCommitLogFilePath); err != nil {
+		return err
+	}
+
+	if len(o.OverrideFilePath) == 0 {
+		o.OverrideFilePath = filepath.Join(o.CarriesDir, o.From, "overrides.yaml")
+		if _, err := os.Stat(o.OverrideFilePath); os.IsNotExist(err) {
+			klog.InfoS("no overrides for the previous release", "path", o.OverrideFilePath)
+			o.OverrideFilePath = ""
+			return o.canWrite()
+		}
+	}
+	if err := isFile(o.OverrideFilePath); err != nil {
+		return err
+	}
+
+	return o.canWrite()
+}
+
+func (o *MigrateOptions) output() string {
+	return filepath.Join(o.CarriesDir, o.To, "overrides.yaml")
+}
+
+func (o *MigrateOptions) canWrite() error {
+	if _, err := os.Stat(o.output()); err == nil && !o.Force {
+		return fmt.Errorf("%q already exists, use --force to overwrite", o.output())
+	}
+	return nil
+}
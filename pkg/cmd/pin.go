@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/pin"
+)
+
+type PinOptions struct {
+	ConfigFilePath string
+}
+
+func NewPinCommand() *cobra.Command {
+	return newPinCommand("pin", "Replaces the openshift dependencies with their forks in each go.mod file of the pin set.", false)
+}
+
+func NewUnpinCommand() *cobra.Command {
+	return newPinCommand("unpin", "Restores the upstream openshift dependencies in each go.mod file of the pin set.", true)
+}
+
+func newPinCommand(verb, short string, unpin bool) *cobra.Command {
+	options := &PinOptions{}
+
+	cmd := &cobra.Command{
+		Use:          verb + " --pins={pin set file path}",
+		Short:        short,
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := isFile(options.ConfigFilePath); err != nil {
+				return err
+			}
+
+			config, err := pin.Load(options.ConfigFilePath)
+			if err != nil {
+				return err
+			}
+
+			var runner Runner
+			if runner, err = pin.New(config, unpin); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, verb+" failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.ConfigFilePath, "pins", options.ConfigFilePath, "path to the pin set file, ie. carries/v1.24/pins.yaml")
+	cmd.MarkFlagFilename("pins")
+	return cmd
+}
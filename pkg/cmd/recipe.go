@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/recipe"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type RunRecipeOptions struct {
+	RecipeFilePath  string
+	Target          string
+	LogDir          string
+	CheckpointEvery int
+}
+
+func NewRunRecipeCommand() *cobra.Command {
+	options := &RunRecipeOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "run-recipe --target=v1.24 --recipe={recipe file path}",
+		Short:        "Runs the post-pick steps of the recipe in order, each step ends in a commit, the steps already committed are skipped.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
+			t, _ := target.Parse(options.Target)
+			r, err := recipe.Load(options.RecipeFilePath)
+			if err != nil {
+				return err
+			}
+			if len(options.LogDir) == 0 {
+				options.LogDir = filepath.Join(os.TempDir(), "rebase-recipe", options.Target)
+			}
+
+			var runner Runner
+			if runner, err = recipe.New(ctx, r, t, options.LogDir, options.CheckpointEvery); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "run-recipe failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.RecipeFilePath, "recipe", options.RecipeFilePath, "path to the recipe file, ie. carries/v1.24/recipe.yaml")
+	cmd.Flags().StringVar(&options.Target, "target", options.Target, "rebase target, ie. v1.24")
+	cmd.Flags().StringVar(&options.LogDir, "log-dir", options.LogDir, "directory where the log of each step is written, defaults to a directory in $TMPDIR")
+	cmd.MarkFlagFilename("recipe")
+	cmd.MarkFlagDirname("log-dir")
+	cmd.Flags().IntVar(&options.CheckpointEvery, "checkpoint-every", options.CheckpointEvery, "record a checkpoint ref after every N steps, 0 to disable, see rollback")
+	return cmd
+}
+
+func (o *RunRecipeOptions) Validate() error {
+	if err := isFile(o.RecipeFilePath); err != nil {
+		return err
+	}
+	if _, err := target.Parse(o.Target); err != nil {
+		return fmt.Errorf("--target - %w", err)
+	}
+	return nil
+}
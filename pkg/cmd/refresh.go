@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/refresh"
+)
+
+type RefreshOptions struct {
+	CarryCommitLogFilePath string
+	Since                  string
+}
+
+func NewRefreshCommand() *cobra.Command {
+	options := &RefreshOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "refresh --carry-commit-file={carry-commit-log-file-path} [--since={openshift/master SHA}]",
+		Short:        "Appends the carry commits merged to openshift/master since the carry commit log was generated.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := isFile(options.CarryCommitLogFilePath); err != nil {
+				return err
+			}
+
+			var runner Runner
+			var err error
+			if runner, err = refresh.New(options.CarryCommitLogFilePath, options.Since); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "refresh failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.CarryCommitLogFilePath, "carry-commit-file", options.CarryCommitLogFilePath, "file containing all commit logs")
+	cmd.MarkFlagFilename("carry-commit-file")
+	cmd.Flags().StringVar(&options.Since, "since", options.Since, "openshift/master commit the log was generated from, defaults to the last one recorded in the log")
+	return cmd
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/backup"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type RollbackOptions struct {
+	Target string
+	To     string
+}
+
+func NewRollbackCommand() *cobra.Command {
+	options := &RollbackOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "rollback --target=v1.24 [--to={backup or checkpoint name}]",
+		Short:        "Lists the backup and checkpoint refs apply, copy and run-recipe recorded, and resets the current branch to the chosen one.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
+			t, _ := target.Parse(options.Target)
+
+			var runner Runner
+			var err error
+			if runner, err = backup.New(ctx, t, options.To); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "rollback failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Target, "target", options.Target, "rebase target, ie. v1.24")
+	cmd.Flags().StringVar(&options.To, "to", options.To, "name of the backup or checkpoint ref to reset the branch to, ie. 20220510-143000-checkpoint-020, the refs are listed if not set")
+	return cmd
+}
+
+func (o *RollbackOptions) Validate() error {
+	if _, err := target.Parse(o.Target); err != nil {
+		return fmt.Errorf("--target - %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+)
+
+type ResolveTargetOptions struct {
+	Target string
+	Print  string
+}
+
+func NewResolveTargetCommand() *cobra.Command {
+	options := &ResolveTargetOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "resolve-target --target=v1.24 [--print=tag|base]",
+		Short:        "Prints the latest upstream tag of the target, and the base release the carry commits are generated from.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			t, err := target.Parse(options.Target)
+			if err != nil {
+				return fmt.Errorf("--target - %w", err)
+			}
+
+			repository, err := git.OpenWorkingDir()
+			if err != nil {
+				return err
+			}
+			tag, base, err := target.Resolve(c.Context(), t, repository)
+			if err != nil {
+				klog.ErrorS(err, "resolve-target failed")
+				return err
+			}
+
+			switch options.Print {
+			case "tag":
+				fmt.Println(tag)
+			case "base":
+				fmt.Println(base)
+			case "":
+				fmt.Printf("tag=%s\nbase=%s\n", tag, base)
+			default:
+				return fmt.Errorf("--print must be either tag or base")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Target, "target", options.Target, "rebase target, ie. v1.24, v1.24.0 or v1.24.0-rc.1")
+	cmd.Flags().StringVar(&options.Print, "print", options.Print, "print only the tag, or the base")
+	return cmd
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/tkashem/rebase/pkg/vendorcheck"
+)
+
+func NewCheckVendorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "check-vendor",
+		Short:        "Reports the inconsistencies between go.mod and vendor/modules.txt of every module in the tree.",
+		Example:      "",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
+			root, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+
+			var runner Runner
+			if runner, err = vendorcheck.New(root); err != nil {
+				return err
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				klog.ErrorS(err, "check-vendor failed")
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
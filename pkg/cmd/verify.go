@@ -5,6 +5,8 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
 	"github.com/tkashem/rebase/pkg/verify"
 )
 
@@ -16,11 +18,12 @@ func NewVerifyCommand() *cobra.Command {
 	options := &VerifyOptions{}
 
 	cmd := &cobra.Command{
-		Use:          "verify --target=v.1.24 --carry-commit-file={carry-commit-log-file-path}",
+		Use:          "verify --target=v1.24 --carry-commit-file={carry-commit-log-file-path}",
 		Short:        "Iterates through the carry commits picked in the branch and compares",
 		Example:      "",
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
+			ctx := c.Context()
 			if err := options.Validate(); err != nil {
 				return err
 			}
@@ -28,17 +31,23 @@ func NewVerifyCommand() *cobra.Command {
 			// TODO: today the carries are obtained from a CSV file, but in
 			//  the following rebase we can generate them on the fly using
 			//  the openshift rebase marker
-			carries, err := carry.NewReaderFromFile(options.CarryCommitLogFilePath, options.OverrideFilePath)
+			repository, err := git.OpenWorkingDir()
 			if err != nil {
 				return err
 			}
+			carries, err := carry.NewReaderFromFile(ctx, options.CarryCommitLogFilePath, options.OverrideFilePath, repository)
+			if err != nil {
+				return err
+			}
+
+			t, _ := target.Parse(options.Target)
 
 			var runner Runner
-			if runner, err = verify.New(carries, options.Target); err != nil {
+			if runner, err = verify.New(carries, t); err != nil {
 				return err
 			}
 
-			if err := runner.Run(); err != nil {
+			if err := runner.Run(ctx); err != nil {
 				klog.ErrorS(err, "verify failed")
 				return err
 			}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"k8s.io/klog/v2"
+)
+
+// Executor executes a shell command, and returns its combined output,
+// the command is killed once the context is done.
+type Executor interface {
+	Execute(ctx context.Context, command string) ([]byte, error)
+}
+
+// NewShellExecutor returns an Executor that runs each command with
+// 'sh -c' in the given directory, an empty directory means the
+// current working directory.
+func NewShellExecutor(dir string) Executor {
+	return &shell{dir: dir}
+}
+
+type shell struct {
+	dir string
+}
+
+func (s *shell) Execute(ctx context.Context, command string) ([]byte, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = s.dir
+	// the command runs in its own process group, so the processes it
+	// starts are killed along with it, a child left behind would hold
+	// the output open, and keep us waiting.
+	setProcessGroup(cmd)
+	output := &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = output, output
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+	err := cmd.Wait()
+	return output.Bytes(), err
+}
+
+// Runner runs a list of commands in order, it stops at the first
+// command that fails.
+type Runner struct {
+	Executor Executor
+
+	// Output, if set, receives each command along with its output
+	Output io.Writer
+}
+
+func (r *Runner) Run(ctx context.Context, commands []string) error {
+	for i, command := range commands {
+		klog.InfoS("executing command", "step", fmt.Sprintf("%d/%d", i+1, len(commands)), "command", command)
+
+		output, err := r.Executor.Execute(ctx, command)
+		if r.Output != nil {
+			fmt.Fprintf(r.Output, "$ %s\n%s", command, output)
+			if err != nil {
+				fmt.Fprintf(r.Output, "error: %v\n", err)
+			}
+		}
+		if len(output) > 0 {
+			klog.Infof("<<<<<<<<<<<<<<<<<<<< OUTPUT: START <<<<<<<<<<<<<<<<<<<<\n%s", output)
+			klog.Infof(">>>>>>>>>>>>>>>>>>>> OUTPUT: END >>>>>>>>>>>>>>>>>>>>>>\n")
+		}
+		if err != nil {
+			return fmt.Errorf("command %q failed: %w", command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type fakeExecutor struct {
+	executed []string
+	fail     map[string]error
+}
+
+func (f *fakeExecutor) Execute(_ context.Context, command string) ([]byte, error) {
+	f.executed = append(f.executed, command)
+	return []byte("output of " + command), f.fail[command]
+}
+
+func TestRunner(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		fail     map[string]error
+		executed []string
+		err      bool
+	}{
+		{
+			name:     "all commands run in order",
+			commands: []string{"make update", "hack/update-vendor.sh"},
+			executed: []string{"make update", "hack/update-vendor.sh"},
+		},
+		{
+			name:     "stops at the first failure",
+			commands: []string{"make update", "hack/update-vendor.sh", "make verify"},
+			fail:     map[string]error{"hack/update-vendor.sh": errors.New("exit status 1")},
+			executed: []string{"make update", "hack/update-vendor.sh"},
+			err:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor := &fakeExecutor{fail: test.fail}
+			runner := &Runner{Executor: executor}
+
+			err := runner.Run(context.TODO(), test.commands)
+			if test.err != (err != nil) {
+				t.Errorf("Expected error: %t, but got: %v", test.err, err)
+			}
+			if !reflect.DeepEqual(test.executed, executor.executed) {
+				t.Errorf("Expected commands: %v, but got: %v", test.executed, executor.executed)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package command
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a noop, the platform has no process group to
+// start the command in.
+func setProcessGroup(_ *exec.Cmd) {}
+
+// killProcessGroup kills the started command only, the processes it
+// started may outlive it.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
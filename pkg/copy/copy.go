@@ -1,23 +1,32 @@
 package copy
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/tkashem/rebase/pkg/backup"
 	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"github.com/tkashem/rebase/pkg/workspace"
 	"k8s.io/klog/v2"
 )
 
-func New(target string, sourceHeadSHA string, sourceMarker string) (*cmd, error) {
-	accessor, err := git.Initialize(target)
+func New(ctx context.Context, t *target.Target, sourceHeadSHA string, sourceMarker *target.Target, mode workspace.Mode, checkpointEvery int) (*cmd, error) {
+	accessor, err := git.Initialize(ctx, t)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize git - %w", err)
 	}
+	guard, err := workspace.New(ctx, accessor.Git, "copy", t.String())
+	if err != nil {
+		return nil, err
+	}
 
 	marker := accessor.Marker
-	if len(sourceMarker) > 0 {
-		marker = fmt.Sprintf("openshift-rebase(%s):marker", sourceMarker)
+	if sourceMarker != nil {
+		marker = sourceMarker.Marker()
 	}
 	klog.InfoS("looking for rebase marker for the source branch", "pattern", marker)
-	sourceStopAt, err := accessor.Git.FindRebaseMarkerCommit(sourceHeadSHA, accessor.Marker)
+	sourceStopAt, err := accessor.Git.FindRebaseMarkerCommit(ctx, sourceHeadSHA, marker)
 	if err != nil {
 		return nil, err
 	}
@@ -28,6 +37,9 @@ func New(target string, sourceHeadSHA string, sourceMarker string) (*cmd, error)
 			accessor:        accessor,
 			sourceHeadSHA:   sourceHeadSHA,
 			sourceStopAtSHA: sourceStopAt.Hash.String(),
+			guard:           guard,
+			mode:            mode,
+			recorder:        backup.NewRecorder(accessor.Git, "copy", t.String(), checkpointEvery),
 		},
 	}, nil
 }
@@ -36,6 +48,18 @@ type cmd struct {
 	copier *copier
 }
 
-func (c *cmd) Run() error {
-	return c.copier.copyAll()
+func (c *cmd) Run(ctx context.Context) error {
+	guard := c.copier.guard
+	if err := guard.Lock(); err != nil {
+		return err
+	}
+	defer guard.Unlock()
+	if err := c.copier.recorder.Backup(ctx); err != nil {
+		return err
+	}
+	if c.copier.mode == workspace.Abort {
+		return guard.Abort(ctx)
+	}
+
+	return c.copier.copyAll(ctx)
 }
@@ -1,12 +1,14 @@
 package copy
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"strings"
 
 	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/backup"
 	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/workspace"
 	"k8s.io/klog/v2"
 )
 
@@ -14,14 +16,27 @@ type copier struct {
 	accessor        *git.Accessor
 	sourceHeadSHA   string
 	sourceStopAtSHA string
+
+	guard    *workspace.Guard
+	mode     workspace.Mode
+	recorder *backup.Recorder
 }
 
-func (c *copier) copyAll() error {
-	klog.InfoS("copy in progress", "target", c.accessor.Target, "marker", c.accessor.Marker, "rebase-marker-sha",
+func (c *copier) copyAll(ctx context.Context) error {
+	// a copy that stopped on a conflict is completed here, and found
+	// as copied below
+	if _, err := c.guard.Preflight(ctx, c.mode); err != nil {
+		return err
+	}
+	if err := c.guard.Finish(); err != nil {
+		return err
+	}
+
+	klog.InfoS("copy in progress", "target", c.accessor.Target.String(), "marker", c.accessor.Marker, "rebase-marker-sha",
 		c.accessor.StopAtCommitSHA, "commit-amend-metadata", c.accessor.MetadataSource, "pick-cherry-picks-from", c.sourceStopAtSHA)
 
 	// this is the list of commits picked in the source branch
-	sourceCommits, err := c.accessor.Git.Log(c.sourceHeadSHA, c.sourceStopAtSHA)
+	sourceCommits, err := c.accessor.Git.Log(ctx, c.sourceHeadSHA, c.sourceStopAtSHA)
 	if err != nil {
 		return err
 	}
@@ -34,15 +49,24 @@ func (c *copier) copyAll() error {
 
 	for i := len(sourceCommits) - 1; i >= 0; i-- {
 		commit := sourceCommits[i]
-		copied, err := c.copied(commit)
+		if ctx.Err() != nil {
+			return c.interrupted(len(sourceCommits)-1-i, len(sourceCommits), commit)
+		}
+		copied, err := c.copied(ctx, commit)
 		if err != nil {
+			if ctx.Err() != nil {
+				return c.interrupted(len(sourceCommits)-1-i, len(sourceCommits), commit)
+			}
 			return err
 		}
 		if copied {
 			continue
 		}
 
-		if err := c.copy(commit); err != nil {
+		if err := c.copy(ctx, commit); err != nil {
+			return err
+		}
+		if err := c.recorder.Applied(ctx); err != nil {
 			return err
 		}
 	}
@@ -50,29 +74,24 @@ func (c *copier) copyAll() error {
 	return nil
 }
 
-func (c *copier) getMetadataSource(msg string) string {
-	reader := strings.NewReader(msg)
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if strings.HasPrefix(line, c.accessor.MetadataSource) {
-			return line
-		}
-	}
-
-	return ""
+// interrupted reports where the copy stopped, a rerun skips the commits
+// that are already copied.
+func (c *copier) interrupted(done, total int, next *gitv5object.Commit) error {
+	klog.InfoS("interrupted, stopped at a safe point", "copied", fmt.Sprintf("%d/%d", done, total), "next", next.Hash.String())
+	klog.Infof("run the same command again to resume from %s", next.Hash.String())
+	return fmt.Errorf("interrupted after %d of %d commits", done, total)
 }
 
-func (c *copier) copied(source *gitv5object.Commit) (bool, error) {
-	commits, err := c.accessor.Git.Log("", c.accessor.StopAtCommitSHA)
+func (c *copier) copied(ctx context.Context, source *gitv5object.Commit) (bool, error) {
+	commits, err := c.accessor.Git.Log(ctx, "", c.accessor.StopAtCommitSHA)
 	if err != nil {
 		return false, fmt.Errorf("git log failed with error: %w", err)
 	}
 
 	// is the source commit a carry from the previous version?
-	carry := c.getMetadataSource(source.Message)
+	carry := git.Metadata(source.Message, c.accessor.MetadataSource)
 	for _, commit := range commits {
-		if (len(carry) > 0 && strings.Contains(commit.Message, carry)) ||
+		if (len(carry) > 0 && git.Metadata(commit.Message, c.accessor.MetadataSource) == carry) ||
 			strings.Contains(commit.Message, source.Message) {
 			return true, nil
 		}
@@ -81,10 +100,14 @@ func (c *copier) copied(source *gitv5object.Commit) (bool, error) {
 	return false, nil
 }
 
-func (c *copier) copy(source *gitv5object.Commit) error {
-	if err := c.accessor.Git.CherryPick(source.Hash.String()); err != nil {
+func (c *copier) copy(ctx context.Context, source *gitv5object.Commit) error {
+	if err := c.guard.Started(ctx, source.Hash.String(), source.Hash.String()); err != nil {
 		return err
 	}
+	if err := c.accessor.Git.CherryPick(ctx, source.Hash.String()); err != nil {
+		return fmt.Errorf("failed to copy %s, resolve the conflict and run copy with --continue, or run it with --abort - %w",
+			source.Hash.String(), err)
+	}
 
-	return nil
+	return c.guard.Finish()
 }
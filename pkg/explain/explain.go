@@ -0,0 +1,141 @@
+package explain
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"k8s.io/klog/v2"
+)
+
+var mergePR = regexp.MustCompile(`^Merge pull request #([0-9]+) `)
+
+func New(repository git.Git, t *target.Target, base string, open bool) (*cmd, error) {
+	return &cmd{
+		git:    repository,
+		target: t.String(),
+		base:   base,
+		open:   open,
+		marker: t.Marker(),
+	}, nil
+}
+
+type cmd struct {
+	git                  git.Git
+	target, base, marker string
+	open                 bool
+}
+
+// Run explains the conflicts of the carry commit being cherry-picked,
+// for each conflicting hunk it lists the upstream commits between the
+// previous base and the rebase target that touched the same lines.
+func (c *cmd) Run(ctx context.Context) error {
+	// the first parent of the rebase marker is the target tag, the
+	// carries picked on top of it are not upstream changes
+	marker, err := c.git.FindRebaseMarkerCommit(ctx, "", c.marker)
+	if err != nil {
+		return fmt.Errorf("rebase marker not found, this branch is not properly setup for rebase - %w", err)
+	}
+	if marker.NumParents() == 0 {
+		return fmt.Errorf("rebase marker %s has no parent", marker.Hash.String())
+	}
+	tag := marker.ParentHashes[0].String()
+
+	sha, err := c.git.CherryPickHead(ctx)
+	if err != nil {
+		return err
+	}
+	carry, err := c.git.Commit(ctx, sha)
+	if err != nil {
+		return err
+	}
+	subject := git.Subject(carry.Message)
+	carryURL := fmt.Sprintf("https://github.com/openshift/kubernetes/commit/%s", sha)
+
+	conflicts, err := c.git.Conflicts(ctx)
+	if err != nil {
+		return err
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "carry: %s\n       %s\n", subject, carryURL)
+	for _, conflict := range conflicts {
+		fmt.Fprintf(b, "\n%s\n", conflict.Path)
+		if len(conflict.Hunks) == 0 {
+			fmt.Fprintf(b, "  deleted on one side\n")
+			continue
+		}
+
+		for _, hunk := range conflict.Hunks {
+			fmt.Fprintf(b, "  %s\n", hunk.String())
+			if hunk.HeadStart == 0 {
+				fmt.Fprintf(b, "    could not locate the hunk at HEAD\n")
+				continue
+			}
+
+			// HEAD has the carries picked so far on top of the tag, the
+			// lines of the hunk are looked up in the tag's version of the file
+			start, end, err := c.git.MapLines(ctx, conflict.Path, "HEAD", tag, hunk.HeadStart, hunk.HeadEnd)
+			if err != nil {
+				return err
+			}
+			if start == 0 {
+				fmt.Fprintf(b, "    added by the carries picked on top of %s\n", c.target)
+				continue
+			}
+
+			entries, err := c.git.LogLines(ctx, fmt.Sprintf("%s..%s", c.base, tag), conflict.Path, start, end)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				fmt.Fprintf(b, "    no change between %s and %s\n", c.base, c.target)
+			}
+			for _, entry := range entries {
+				fmt.Fprintf(b, "    %s %s\n      %s\n", entry.SHA[:11], entry.Subject, link(entry))
+			}
+		}
+	}
+
+	klog.Infof("conflicts(%d):\n%s", len(conflicts), b.String())
+	if c.open {
+		openBrowser(carryURL)
+	}
+	return nil
+}
+
+// link returns the URL that best explains the given commit, an upstream
+// merge commit links to its PR, a carry to its openshift commit.
+func link(entry git.LogEntry) string {
+	if match := mergePR.FindStringSubmatch(entry.Subject); len(match) == 2 {
+		return fmt.Sprintf("https://github.com/kubernetes/kubernetes/pull/%s", match[1])
+	}
+	if strings.HasPrefix(entry.Subject, "UPSTREAM: ") {
+		return fmt.Sprintf("https://github.com/openshift/kubernetes/commit/%s", entry.SHA)
+	}
+	return fmt.Sprintf("https://github.com/kubernetes/kubernetes/commit/%s", entry.SHA)
+}
+
+// open a browser window with the given URL
+func openBrowser(url string) {
+	var err error
+
+	switch runtime.GOOS {
+	case "linux":
+		err = exec.Command("xdg-open", url).Start()
+	case "windows":
+		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		err = exec.Command("open", url).Start()
+	default:
+		err = fmt.Errorf("unsupported platform")
+	}
+	if err != nil {
+		klog.ErrorS(err, "failed to open a browser window")
+	}
+}
@@ -0,0 +1,199 @@
+package forecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+	"k8s.io/klog/v2"
+)
+
+type Verdict string
+
+const (
+	Clean          Verdict = "clean"
+	Conflict       Verdict = "conflict"
+	AlreadyApplied Verdict = "already-applied"
+	Dropped        Verdict = "drop"
+	Regenerated    Verdict = "regenerate"
+)
+
+func New(reader carry.CommitReader, repository git.Git, tag string) (*cmd, error) {
+	return &cmd{
+		reader: reader,
+		git:    repository,
+		tag:    tag,
+	}, nil
+}
+
+type cmd struct {
+	reader carry.CommitReader
+	git    git.Git
+	tag    string
+}
+
+type forecast struct {
+	commit  *carry.CommitSummary
+	verdict Verdict
+	paths   []string
+}
+
+// Run forecasts, for each carry commit, whether it applies on top of
+// the target tag. Nothing is written to the repository, the carries
+// are applied in order to an in memory overlay of the target tree, so
+// a carry that builds on a previous carry is forecast correctly.
+func (c *cmd) Run(ctx context.Context) error {
+	target, err := c.git.Commit(ctx, c.tag)
+	if err != nil {
+		return fmt.Errorf("failed to find target %s - %w", c.tag, err)
+	}
+	klog.InfoS("forecast in progress", "target", c.tag, "sha", target.Hash.String())
+
+	carries, err := c.reader.Read(ctx)
+	if err != nil {
+		return err
+	}
+
+	o := &overlay{git: c.git, target: target.Hash.String(), files: map[string]*string{}}
+	forecasts := make([]forecast, 0, len(carries))
+	for _, commit := range carries {
+		f, err := c.forecast(ctx, o, commit)
+		if err != nil {
+			return err
+		}
+		klog.V(2).Infof("forecast(%s) - %s", f.verdict, commit.String())
+		forecasts = append(forecasts, f)
+	}
+
+	c.print(forecasts)
+	return nil
+}
+
+func (c *cmd) forecast(ctx context.Context, o *overlay, commit *carry.CommitSummary) (forecast, error) {
+	if commit.EffectiveType == "drop" {
+		return forecast{commit: commit, verdict: Dropped}, nil
+	}
+	if commit.EffectiveType == "regenerate" {
+		// the commands run against the target, there is nothing to merge
+		return forecast{commit: commit, verdict: Regenerated}, nil
+	}
+
+	object, err := c.git.Commit(ctx, commit.SHA)
+	if err != nil {
+		return forecast{}, err
+	}
+	if object.NumParents() == 0 {
+		return forecast{}, fmt.Errorf("carry commit has no parent - %s", commit.String())
+	}
+	parent := object.ParentHashes[0].String()
+
+	paths, err := c.git.ChangedFiles(ctx, commit.SHA)
+	if err != nil {
+		return forecast{}, err
+	}
+
+	merged := map[string]*string{}
+	conflicts := make([]string, 0)
+	applied := 0
+	for _, path := range paths {
+		base, err := read(ctx, c.git, parent, path)
+		if err != nil {
+			return forecast{}, err
+		}
+		theirs, err := read(ctx, c.git, commit.SHA, path)
+		if err != nil {
+			return forecast{}, err
+		}
+		ours, err := o.read(ctx, path)
+		if err != nil {
+			return forecast{}, err
+		}
+
+		switch {
+		case equal(ours, theirs):
+			applied++
+		case equal(base, ours):
+			merged[path] = theirs
+		case base == nil || ours == nil || theirs == nil:
+			// added on both sides, or deleted on one side and modified on the other
+			conflicts = append(conflicts, path)
+		default:
+			content, ok := merge(*base, *ours, *theirs)
+			if !ok {
+				conflicts = append(conflicts, path)
+				continue
+			}
+			merged[path] = &content
+		}
+	}
+
+	switch {
+	case len(conflicts) > 0:
+		// the carry would not be picked, the overlay stays as is
+		return forecast{commit: commit, verdict: Conflict, paths: conflicts}, nil
+	case applied == len(paths):
+		return forecast{commit: commit, verdict: AlreadyApplied}, nil
+	}
+
+	for path, content := range merged {
+		o.files[path] = content
+	}
+	return forecast{commit: commit, verdict: Clean}, nil
+}
+
+func (c *cmd) print(forecasts []forecast) {
+	counts := map[Verdict]int{}
+	b := &strings.Builder{}
+	for _, f := range forecasts {
+		counts[f.verdict]++
+		fmt.Fprintf(b, "%-16s %s\t%s\n", f.verdict, f.commit.ShortSHA(), f.commit.MessageWithPrefix)
+		for _, path := range f.paths {
+			fmt.Fprintf(b, "%-16s   %s\n", "", path)
+		}
+	}
+
+	klog.Infof("forecast against %s:\n%s", c.tag, b.String())
+	klog.Infof("stats: total(%d), clean(%d), conflict(%d), already-applied(%d), drop(%d), regenerate(%d)", len(forecasts),
+		counts[Clean], counts[Conflict], counts[AlreadyApplied], counts[Dropped], counts[Regenerated])
+}
+
+// overlay is the tree of the target, along with the changes of the
+// carries forecast to apply cleanly so far.
+type overlay struct {
+	git    git.Git
+	target string
+	// nil content means the file has been deleted
+	files map[string]*string
+}
+
+func (o *overlay) read(ctx context.Context, path string) (*string, error) {
+	if content, ok := o.files[path]; ok {
+		return content, nil
+	}
+	return read(ctx, o.git, o.target, path)
+}
+
+// read returns the content of the file at the given revision, nil if
+// the file does not exist.
+func read(ctx context.Context, repository git.Git, revision, path string) (*string, error) {
+	content, err := repository.ReadFile(ctx, revision, path)
+	if err != nil {
+		if errors.Is(err, gitv5object.ErrFileNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	s := string(content)
+	return &s, nil
+}
+
+func equal(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,101 @@
+package forecast
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// region is a change relative to the base version of a file, it
+// replaces the lines [start, end) of the base with text. An insertion
+// has start == end.
+type region struct {
+	start, end int
+	text       string
+}
+
+// changes returns the regions that turn base into other.
+func changes(base, other string) []region {
+	regions := make([]region, 0)
+	line := 0
+	var current *region
+	flush := func() {
+		if current != nil {
+			regions = append(regions, *current)
+			current = nil
+		}
+	}
+
+	for _, d := range diff.Do(base, other) {
+		n := lines(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			flush()
+			line += n
+		case diffmatchpatch.DiffDelete:
+			if current == nil {
+				current = &region{start: line, end: line}
+			}
+			current.end += n
+			line += n
+		case diffmatchpatch.DiffInsert:
+			if current == nil {
+				current = &region{start: line, end: line}
+			}
+			current.text += d.Text
+		}
+	}
+	flush()
+	return regions
+}
+
+func lines(text string) int {
+	n := strings.Count(text, "\n")
+	if len(text) > 0 && !strings.HasSuffix(text, "\n") {
+		n++
+	}
+	return n
+}
+
+// merge applies both the changes from base to ours, and the changes
+// from base to theirs to base. Like git, changes that touch or overlap
+// each other conflict, unless they are identical. The bool reports
+// whether the merge is clean, the merged text is empty if it is not.
+func merge(base, ours, theirs string) (string, bool) {
+	left, right := changes(base, ours), changes(base, theirs)
+	all := make([]region, 0, len(left)+len(right))
+	all = append(all, left...)
+	for _, r := range right {
+		duplicate := false
+		for _, l := range left {
+			if l.start > r.end || r.start > l.end {
+				continue
+			}
+			if l != r {
+				return "", false
+			}
+			duplicate = true
+		}
+		if !duplicate {
+			all = append(all, r)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].start < all[j].start })
+
+	baseLines := strings.SplitAfter(base, "\n")
+	b := &strings.Builder{}
+	line := 0
+	for _, r := range all {
+		for ; line < r.start; line++ {
+			b.WriteString(baseLines[line])
+		}
+		b.WriteString(r.text)
+		line = r.end
+	}
+	for ; line < len(baseLines); line++ {
+		b.WriteString(baseLines[line])
+	}
+	return b.String(), true
+}
@@ -0,0 +1,59 @@
+package forecast
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := "a\nb\nc\nd\ne\nf\n"
+	tests := []struct {
+		name         string
+		ours, theirs string
+		expected     string
+		mergeable    bool
+	}{
+		{
+			name:      "changes far apart",
+			ours:      "a\nB\nc\nd\ne\nf\n",
+			theirs:    "a\nb\nc\nd\nE\nf\ng\n",
+			expected:  "a\nB\nc\nd\nE\nf\ng\n",
+			mergeable: true,
+		},
+		{
+			name:      "identical changes",
+			ours:      "a\nb\nC\nd\ne\nf\n",
+			theirs:    "a\nb\nC\nd\ne\nf\n",
+			expected:  "a\nb\nC\nd\ne\nf\n",
+			mergeable: true,
+		},
+		{
+			name:   "overlapping changes",
+			ours:   "a\nb\nC\nd\ne\nf\n",
+			theirs: "a\nb\nx\nd\ne\nf\n",
+		},
+		{
+			name:   "adjacent changes",
+			ours:   "a\nb\nC\nd\ne\nf\n",
+			theirs: "a\nb\nc\nD\ne\nf\n",
+		},
+		{
+			name:      "insertion and deletion",
+			ours:      "a\nb\nc\nd\nf\n",
+			theirs:    "x\na\nb\nc\nd\ne\nf\n",
+			expected:  "x\na\nb\nc\nd\nf\n",
+			mergeable: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, ok := merge(base, test.ours, test.theirs)
+			if ok != test.mergeable {
+				t.Fatalf("Expected mergeable: %t, but got: %t", test.mergeable, ok)
+			}
+			if ok && merged != test.expected {
+				t.Errorf("Expected merged content: %q, but got: %q", test.expected, merged)
+			}
+		})
+	}
+}
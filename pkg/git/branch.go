@@ -0,0 +1,106 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"k8s.io/klog/v2"
+)
+
+// CurrentBranch returns the short name of the branch HEAD points to.
+func (git *git) CurrentBranch(ctx context.Context) (string, error) {
+	ref, err := git.repository.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if !ref.Name().IsBranch() {
+		return "", fmt.Errorf("HEAD is detached at %s", ref.Hash().String())
+	}
+	return ref.Name().Short(), nil
+}
+
+// BranchExists returns true if the given local branch exists.
+func (git *git) BranchExists(ctx context.Context, name string) (bool, error) {
+	_, err := git.repository.Reference(plumbing.NewBranchReferenceName(name), false)
+	switch {
+	case err == plumbing.ErrReferenceNotFound:
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to look up branch %s - %w", name, err)
+	}
+	return true, nil
+}
+
+// CreateBranch creates a new branch at the given start point, and
+// checks it out.
+func (git *git) CreateBranch(ctx context.Context, name, startPoint string) error {
+	return execute("creating branch", "checkout", "-b", name, startPoint)
+}
+
+// MergeOurs records a merge of the given commit into the current
+// branch with the 'ours' strategy, the tree of HEAD is kept as is.
+func (git *git) MergeOurs(ctx context.Context, sha, message string) error {
+	return execute("merging with ours strategy", "merge", "-s", "ours", "-m", message, sha)
+}
+
+// Ref is a git reference, along with the commit it points to.
+type Ref struct {
+	Name, SHA string
+}
+
+// CreateRef points the given new reference to the given commit, the
+// reason is recorded in the reflog of the reference. It fails if the
+// reference exists, so it never overwrites another one.
+func (git *git) CreateRef(ctx context.Context, name, sha, reason string) error {
+	return execute("creating ref", "update-ref", "-m", reason, name, sha, plumbing.ZeroHash.String())
+}
+
+// ListRefs returns the references under the given prefix, ie.
+// refs/rebase-backup/, sorted by name.
+func (git *git) ListRefs(ctx context.Context, prefix string) ([]Ref, error) {
+	cmd := command(ctx, "for-each-ref", "--sort=refname", "--format=%(refname)%09%(objectname)", prefix)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+
+	refs := make([]Ref, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		split := strings.Split(line, "\t")
+		if len(split) != 2 {
+			continue
+		}
+		refs = append(refs, Ref{Name: split[0], SHA: split[1]})
+	}
+	return refs, nil
+}
+
+// execute runs git with the given arguments to change the branch, and
+// logs its output.
+func execute(description string, args ...string) error {
+	return run(description, change(args...))
+}
+
+// run runs the given git command that changes the branch, and logs its
+// output, the command may have its own environment, or input.
+func run(description string, cmd *exec.Cmd) error {
+	var stdoutStderr []byte
+	var err error
+
+	klog.InfoS(description, "command", cmd.String())
+	defer func() {
+		if len(stdoutStderr) > 0 {
+			defer klog.Infof(">>>>>>>>>>>>>>>>>>>> OUTPUT: END >>>>>>>>>>>>>>>>>>>>>>\n")
+			klog.Infof("<<<<<<<<<<<<<<<<<<<< OUTPUT: START <<<<<<<<<<<<<<<<<<<<\n%s", stdoutStderr)
+		}
+	}()
+
+	stdoutStderr, err = cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s failed: %w", cmd.Args[1], err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Conflict is a file left with conflict markers by a cherry-pick.
+type Conflict struct {
+	Path  string
+	Hunks []Hunk
+}
+
+// Hunk is a region of a conflicted file enclosed by conflict markers,
+// Start and End are the (1-based) line numbers of the markers.
+type Hunk struct {
+	Start, End int
+	// Ours and Theirs are the number of lines on each side
+	Ours, Theirs int
+	// HeadStart and HeadEnd are the (1-based, inclusive) lines of the
+	// file at HEAD the conflict is on, zero if they can't be located.
+	HeadStart, HeadEnd int
+}
+
+func (h Hunk) String() string {
+	return fmt.Sprintf("lines %d-%d (ours: %d, theirs: %d)", h.Start, h.End, h.Ours, h.Theirs)
+}
+
+// Conflicts returns the unmerged paths of the index, along with the
+// conflicting hunks of each file in the working tree.
+func (git *git) Conflicts(ctx context.Context) ([]Conflict, error) {
+	cmd := command(ctx, "diff", "--name-only", "--diff-filter=U")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+
+	root, err := git.root()
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]Conflict, 0)
+	for _, path := range strings.Fields(string(out)) {
+		hunks, ours, err := readHunks(filepath.Join(root, path))
+		if err != nil {
+			// the file may have been deleted on one side
+			if os.IsNotExist(err) {
+				conflicts = append(conflicts, Conflict{Path: path})
+				continue
+			}
+			return nil, err
+		}
+
+		if head, err := git.ReadFile(ctx, "HEAD", path); err == nil {
+			locate(strings.Split(string(head), "\n"), hunks, ours)
+		}
+		conflicts = append(conflicts, Conflict{Path: path, Hunks: hunks})
+	}
+	return conflicts, nil
+}
+
+// locate finds the lines of each hunk in the file at HEAD, the ours
+// side of a conflict is the content of HEAD, so we look for it in order.
+func locate(head []string, hunks []Hunk, ours [][]string) {
+	cursor := 0
+	for i := range hunks {
+		lines := ours[i]
+		if len(lines) == 0 {
+			continue
+		}
+		for start := cursor; start+len(lines) <= len(head); start++ {
+			if equalLines(head[start:start+len(lines)], lines) {
+				hunks[i].HeadStart, hunks[i].HeadEnd = start+1, start+len(lines)
+				cursor = start + len(lines)
+				break
+			}
+		}
+	}
+}
+
+func equalLines(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// LogEntry is a commit in the output of LogLines, or LogRange.
+type LogEntry struct {
+	SHA, Subject string
+}
+
+// CherryPickHead returns the SHA of the commit being cherry-picked, it
+// returns an error if no cherry-pick is in progress.
+func (git *git) CherryPickHead(ctx context.Context) (string, error) {
+	reference, err := git.repository.Reference(plumbing.ReferenceName("CHERRY_PICK_HEAD"), true)
+	if err != nil {
+		return "", fmt.Errorf("no cherry-pick in progress - %w", err)
+	}
+	return reference.Hash().String(), nil
+}
+
+// LogLines returns the commits in the given range that touched the
+// given lines of the file, like 'git log -L'. The first parent is
+// followed, so an upstream PR shows up as its merge commit.
+func (git *git) LogLines(ctx context.Context, revisionRange, path string, start, end int) ([]LogEntry, error) {
+	cmd := command(ctx, "log", "--first-parent", "--no-patch", "--format=%H%x09%s",
+		fmt.Sprintf("-L%d,%d:%s", start, end, path), revisionRange)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+	return parseLogEntries(out), nil
+}
+
+// MapLines returns the lines of the file at revision 'to' that the given
+// lines of the file at revision 'from' correspond to, a line changed in
+// between maps to the hunk it was changed in. It returns zeros if none
+// of the lines exist at 'to', ie. all of them were added after it.
+func (git *git) MapLines(ctx context.Context, path, from, to string, start, end int) (int, int, error) {
+	cmd := command(ctx, "diff", "--no-color", "--no-ext-diff", "-U0", to, from, "--", path)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+	start, end = mapLines(parseDiffHunks(out), start, end)
+	return start, end, nil
+}
+
+// diffHunk is the header of a hunk of a zero context diff, the old
+// side starts at line OldStart and has OldLines lines, and so on.
+type diffHunk struct {
+	OldStart, OldLines, NewStart, NewLines int
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -([0-9]+)(?:,([0-9]+))? \+([0-9]+)(?:,([0-9]+))? @@`)
+
+func parseDiffHunks(out []byte) []diffHunk {
+	count := func(s string) int {
+		if len(s) == 0 {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	hunks := make([]diffHunk, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		match := hunkHeader.FindStringSubmatch(line)
+		if len(match) != 5 {
+			continue
+		}
+		oldStart, _ := strconv.Atoi(match[1])
+		newStart, _ := strconv.Atoi(match[3])
+		hunks = append(hunks, diffHunk{OldStart: oldStart, OldLines: count(match[2]), NewStart: newStart, NewLines: count(match[4])})
+	}
+	return hunks
+}
+
+// mapLines maps the given lines on the new side of the diff to the
+// old side, the hunks are in the order git prints them.
+func mapLines(hunks []diffHunk, start, end int) (int, int) {
+	mapped := func(line int, first bool) int {
+		offset := 0
+		for _, h := range hunks {
+			// a hunk that only removes lines is after line NewStart
+			if line < h.NewStart || (h.NewLines == 0 && line == h.NewStart) {
+				break
+			}
+			if line < h.NewStart+h.NewLines {
+				// the line was changed, the hunk it was changed in
+				// stands for it on the old side
+				if h.OldLines == 0 {
+					if first {
+						return h.OldStart + 1
+					}
+					return h.OldStart
+				}
+				if first {
+					return h.OldStart
+				}
+				return h.OldStart + h.OldLines - 1
+			}
+			offset += h.OldLines - h.NewLines
+		}
+		return line + offset
+	}
+
+	start, end = mapped(start, true), mapped(end, false)
+	if start > end || end == 0 {
+		return 0, 0
+	}
+	return start, end
+}
+
+// LogRange returns the non-merge commits in the given range, oldest
+// first. A commit of a side branch that forked before the start of the
+// range, and merged after it, is in the range too.
+func (git *git) LogRange(ctx context.Context, revisionRange string) ([]LogEntry, error) {
+	cmd := command(ctx, "log", "--no-merges", "--reverse", "--format=%H%x09%s", revisionRange)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+	return parseLogEntries(out), nil
+}
+
+// parseLogEntries parses the output of git log with --format=%H%x09%s
+func parseLogEntries(out []byte) []LogEntry {
+	entries := make([]LogEntry, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		split := strings.SplitN(line, "\t", 2)
+		if len(split) != 2 || len(split[0]) != len(plumbing.ZeroHash)*2 {
+			continue
+		}
+		entries = append(entries, LogEntry{SHA: split[0], Subject: split[1]})
+	}
+	return entries
+}
+
+func (git *git) root() (string, error) {
+	worktree, err := git.repository.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the worktree - %w", err)
+	}
+	return worktree.Filesystem.Root(), nil
+}
+
+// readHunks returns the conflicting hunks of the given file, along
+// with the lines on the ours side of each hunk.
+func readHunks(path string) ([]Hunk, [][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	const (
+		outside = iota
+		ours
+		base
+		theirs
+	)
+
+	hunks, lines := make([]Hunk, 0), make([][]string, 0)
+	current, state := Hunk{}, outside
+	var oursLines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "<<<<<<< "):
+			current, state, oursLines = Hunk{Start: line}, ours, nil
+		case state == outside:
+		case strings.HasPrefix(text, "||||||| "):
+			// diff3 style, the merge base is neither ours nor theirs
+			state = base
+		case text == "=======":
+			state = theirs
+		case strings.HasPrefix(text, ">>>>>>> "):
+			current.End = line
+			hunks, lines = append(hunks, current), append(lines, oursLines)
+			state = outside
+		case state == ours:
+			current.Ours++
+			oursLines = append(oursLines, text)
+		case state == theirs:
+			current.Theirs++
+		}
+	}
+	return hunks, lines, scanner.Err()
+}
@@ -0,0 +1,150 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadHunks(t *testing.T) {
+	content := `package foo
+<<<<<<< HEAD
+func a() {}
+func b() {}
+=======
+func c() {}
+>>>>>>> 8bd488b66eb (UPSTREAM: <carry>: foo)
+var x = 1
+<<<<<<< HEAD
+var y = 1
+||||||| parent of 8bd488b66eb (UPSTREAM: <carry>: foo)
+var y = 0
+=======
+var y = 2
+var z = 2
+>>>>>>> 8bd488b66eb (UPSTREAM: <carry>: foo)
+`
+	path := filepath.Join(t.TempDir(), "foo.go")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	hunks, ours, err := readHunks(path)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	expected := []Hunk{
+		{Start: 2, End: 7, Ours: 2, Theirs: 1},
+		{Start: 9, End: 16, Ours: 1, Theirs: 2},
+	}
+	if !reflect.DeepEqual(expected, hunks) {
+		t.Errorf("Expected hunks: %v, but got: %v", expected, hunks)
+	}
+
+	head := []string{"package foo", "func a() {}", "func b() {}", "var x = 1", "var y = 1", ""}
+	locate(head, hunks, ours)
+	for i, lines := range [][2]int{{2, 3}, {5, 5}} {
+		if hunks[i].HeadStart != lines[0] || hunks[i].HeadEnd != lines[1] {
+			t.Errorf("Expected hunk[%d] at HEAD lines %d-%d, but got: %d-%d", i, lines[0], lines[1], hunks[i].HeadStart, hunks[i].HeadEnd)
+		}
+	}
+}
+
+func TestMapLines(t *testing.T) {
+	// HEAD has lines 3-4 added, line 8 changed, and line 12 of the tag removed
+	diff := []byte(`diff --git a/foo.go b/foo.go
+index 1111111..2222222 100644
+--- a/foo.go
++++ b/foo.go
+@@ -2,0 +3,2 @@ package foo
++func c() {}
++func d() {}
+@@ -6 +8 @@ func a() {}
+-var x = 1
++var x = 2
+@@ -12 +13,0 @@ var y = 1
+-var z = 1
+`)
+	hunks := parseDiffHunks(diff)
+
+	tests := []struct {
+		name       string
+		start, end int
+		expected   [2]int
+	}{
+		{name: "before any change", start: 1, end: 2, expected: [2]int{1, 2}},
+		{name: "added at HEAD only", start: 3, end: 4, expected: [2]int{0, 0}},
+		{name: "after an addition", start: 5, end: 7, expected: [2]int{3, 5}},
+		{name: "changed at HEAD", start: 8, end: 8, expected: [2]int{6, 6}},
+		{name: "spans an addition", start: 2, end: 5, expected: [2]int{2, 3}},
+		{name: "before a removal", start: 13, end: 13, expected: [2]int{11, 11}},
+		{name: "after a removal", start: 14, end: 15, expected: [2]int{13, 14}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end := mapLines(hunks, test.start, test.end)
+			if got := [2]int{start, end}; got != test.expected {
+				t.Errorf("Expected lines %v, but got: %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestLogRange(t *testing.T) {
+	dir := t.TempDir()
+	run := func(date int, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=rebase", "GIT_AUTHOR_EMAIL=rebase@example.com",
+			"GIT_COMMITTER_NAME=rebase", "GIT_COMMITTER_EMAIL=rebase@example.com",
+			fmt.Sprintf("GIT_AUTHOR_DATE=%d +0000", 1650000000+date),
+			fmt.Sprintf("GIT_COMMITTER_DATE=%d +0000", 1650000000+date))
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s failed: %v\n%s", cmd.String(), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	// the side branch forks before 'since', and merges after it
+	run(0, "init", "--quiet")
+	run(0, "symbolic-ref", "HEAD", "refs/heads/master")
+	run(0, "commit", "--quiet", "--allow-empty", "-m", "UPSTREAM: <carry>: a")
+	run(1, "checkout", "--quiet", "-b", "side")
+	run(1, "commit", "--quiet", "--allow-empty", "-m", "UPSTREAM: <carry>: side")
+	run(2, "checkout", "--quiet", "master")
+	run(2, "commit", "--quiet", "--allow-empty", "-m", "UPSTREAM: <carry>: b")
+	since := run(2, "rev-parse", "HEAD")
+	run(3, "commit", "--quiet", "--allow-empty", "-m", "UPSTREAM: <carry>: c")
+	run(4, "merge", "--quiet", "--no-ff", "-m", "Merge pull request #1 from side", "side")
+	head := run(4, "rev-parse", "HEAD")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	entries, err := (&git{}).LogRange(context.TODO(), since+".."+head)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	got := make([]string, 0)
+	for _, entry := range entries {
+		got = append(got, entry.Subject)
+	}
+	expected := []string{"UPSTREAM: <carry>: side", "UPSTREAM: <carry>: c"}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected commits: %v, but got: %v", expected, got)
+	}
+}
@@ -0,0 +1,23 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+)
+
+// command returns a git command that reads from the repository, it is
+// killed once the context is done.
+func command(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "git", args...)
+}
+
+// change returns a git command that changes the branch, the index or
+// the working tree. It is not bound to a context, so it is never killed
+// half-way, a killed git would leave a lock file or a half-applied pick
+// behind. Where the platform allows, it runs in its own process group,
+// so an interrupt from the terminal reaches us, and not git.
+func change(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	setProcessGroup(cmd)
+	return cmd
+}
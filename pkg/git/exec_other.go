@@ -0,0 +1,12 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package git
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a noop, the platform has no process group we can
+// start git in, an interrupt from the terminal reaches git too.
+func setProcessGroup(_ *exec.Cmd) {}
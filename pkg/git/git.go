@@ -1,9 +1,9 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 
 	gitv5 "github.com/go-git/go-git/v5"
@@ -13,17 +13,48 @@ import (
 )
 
 type Git interface {
-	CheckRemotes() error
-	FindRebaseMarkerCommit(from string, marker string) (*gitv5object.Commit, error)
-	Head() (*gitv5object.Commit, error)
-	Log(from string, stopAtHash string) ([]*gitv5object.Commit, error)
-	CherryPick(sha string) error
-	AbortCherryPick() error
-	AmendCommitMessage(f func(string) []string) error
+	CheckRemotes(ctx context.Context) error
+	FindRebaseMarkerCommit(ctx context.Context, from string, marker string) (*gitv5object.Commit, error)
+	Head(ctx context.Context) (*gitv5object.Commit, error)
+	Log(ctx context.Context, from string, stopAtHash string) ([]*gitv5object.Commit, error)
+	CherryPick(ctx context.Context, sha string) error
+	AbortCherryPick(ctx context.Context) error
+	AmendCommitMessage(ctx context.Context, f func(string) []string) error
+	CommitAll(ctx context.Context, messages []string) error
+	CommitFiles(ctx context.Context, paths []string, messages []string) error
+	ChangedFiles(ctx context.Context, sha string) ([]string, error)
+	Commit(ctx context.Context, sha string) (*gitv5object.Commit, error)
+	ResolveSHA(ctx context.Context, sha string) (string, error)
+	CommitMessage(ctx context.Context, sha string) (string, error)
+	ReadFile(ctx context.Context, revision, path string) ([]byte, error)
+	Conflicts(ctx context.Context) ([]Conflict, error)
+	CherryPickHead(ctx context.Context) (string, error)
+	LogLines(ctx context.Context, revisionRange, path string, start, end int) ([]LogEntry, error)
+	LogRange(ctx context.Context, revisionRange string) ([]LogEntry, error)
+	MapLines(ctx context.Context, path, from, to string, start, end int) (int, int, error)
+	Moves(ctx context.Context, from, to string) (*Moves, error)
+	PickRewritten(ctx context.Context, sha string, renamed map[string]string) error
+	ResetHard(ctx context.Context, sha string) error
+	RemoteTags(ctx context.Context, remote string) ([]string, error)
+	CurrentBranch(ctx context.Context) (string, error)
+	BranchExists(ctx context.Context, name string) (bool, error)
+	CreateBranch(ctx context.Context, name, startPoint string) error
+	MergeOurs(ctx context.Context, sha, message string) error
+	Workspace(ctx context.Context) (*Workspace, error)
+	ContinueCherryPick(ctx context.Context) error
+	CreateRef(ctx context.Context, name, sha, reason string) error
+	ListRefs(ctx context.Context, prefix string) ([]Ref, error)
+	AddWorktree(ctx context.Context, path, commitish string) error
+	RemoveWorktree(ctx context.Context, path string) error
+	CheckoutDetached(ctx context.Context, path, sha string) error
+	LogPaths(ctx context.Context, revisionRange string, paths []string, max int) ([]LogEntry, error)
+	Patch(ctx context.Context, sha string, paths []string) (string, error)
+	CommitEmptyCherryPick(ctx context.Context) error
 }
 
 func OpenGit(path string) (Git, error) {
-	repository, err := gitv5.PlainOpen(path)
+	// a linked worktree keeps its objects and refs in the common dir
+	repository, err := gitv5.PlainOpenWithOptions(path, &gitv5.PlainOpenOptions{EnableDotGitCommonDir: true})
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +65,7 @@ type git struct {
 	repository *gitv5.Repository
 }
 
-func (git *git) CheckRemotes() error {
+func (git *git) CheckRemotes(ctx context.Context) error {
 	for _, remote := range []struct {
 		name string
 		path string
@@ -60,10 +91,14 @@ func (git *git) CheckRemotes() error {
 	return nil
 }
 
-func (git *git) FindRebaseMarkerCommit(from string, marker string) (*gitv5object.Commit, error) {
+func (git *git) FindRebaseMarkerCommit(ctx context.Context, from string, marker string) (*gitv5object.Commit, error) {
 	o := &gitv5.LogOptions{}
 	if len(from) > 0 {
-		o.From = plumbing.NewHash(from)
+		hash, err := git.resolve(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		o.From = hash
 	}
 	iter, err := git.repository.Log(o)
 	if err != nil {
@@ -72,6 +107,9 @@ func (git *git) FindRebaseMarkerCommit(from string, marker string) (*gitv5object
 
 	defer iter.Close()
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		commit, err := iter.Next()
 		if err != nil {
 			return nil, fmt.Errorf("failed to find commit with marker: %s - %w", marker, err)
@@ -81,14 +119,16 @@ func (git *git) FindRebaseMarkerCommit(from string, marker string) (*gitv5object
 			return commit, nil
 		}
 	}
-
-	return nil, fmt.Errorf("failed to find commit with marker: %s", marker)
 }
 
-func (git *git) Log(from, stopAtHash string) ([]*gitv5object.Commit, error) {
+func (git *git) Log(ctx context.Context, from, stopAtHash string) ([]*gitv5object.Commit, error) {
 	o := &gitv5.LogOptions{}
 	if len(from) > 0 {
-		o.From = plumbing.NewHash(from)
+		hash, err := git.resolve(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		o.From = hash
 	}
 
 	iter, err := git.repository.Log(o)
@@ -99,6 +139,9 @@ func (git *git) Log(from, stopAtHash string) ([]*gitv5object.Commit, error) {
 	defer iter.Close()
 	commits := make([]*gitv5object.Commit, 0)
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		commit, err := iter.Next()
 		if err != nil {
 			if err == io.EOF {
@@ -116,9 +159,9 @@ func (git *git) Log(from, stopAtHash string) ([]*gitv5object.Commit, error) {
 	return commits, nil
 }
 
-func (git *git) CherryPick(sha string) error {
+func (git *git) CherryPick(ctx context.Context, sha string) error {
 	// skipping --strategy-option=ours
-	cmd := exec.Command("git", "cherry-pick", "--allow-empty", sha)
+	cmd := change("cherry-pick", "--allow-empty", sha)
 
 	var stdoutStderr []byte
 	var err error
@@ -138,8 +181,8 @@ func (git *git) CherryPick(sha string) error {
 	return nil
 }
 
-func (git *git) AbortCherryPick() error {
-	cmd := exec.Command("git", "cherry-pick", "--abort")
+func (git *git) AbortCherryPick(ctx context.Context) error {
+	cmd := change("cherry-pick", "--abort")
 
 	var stdoutStderr []byte
 	var err error
@@ -159,7 +202,13 @@ func (git *git) AbortCherryPick() error {
 	return nil
 }
 
-func (git *git) AmendCommitMessage(f func(string) []string) error {
+// ResetHard resets the current branch, the index and the working
+// tree to the given commit.
+func (git *git) ResetHard(ctx context.Context, sha string) error {
+	return execute("resetting branch", "reset", "--hard", sha)
+}
+
+func (git *git) AmendCommitMessage(ctx context.Context, f func(string) []string) error {
 	var err error
 	current, err := git.getCommitMessageAtHead()
 	if err != nil {
@@ -171,7 +220,7 @@ func (git *git) AmendCommitMessage(f func(string) []string) error {
 		args = append(args, "-m", msg)
 	}
 
-	cmd := exec.Command("git", args...)
+	cmd := change(args...)
 	klog.InfoS("amend commit message", "command", cmd.String())
 
 	var stdoutStderr []byte
@@ -189,7 +238,209 @@ func (git *git) AmendCommitMessage(f func(string) []string) error {
 	return nil
 }
 
-func (git *git) Head() (*gitv5object.Commit, error) {
+// CommitAll stages every change in the working tree, and creates a
+// new commit with the given message paragraphs.
+func (git *git) CommitAll(ctx context.Context, messages []string) error {
+	if err := execute("staging changes", "add", "-A"); err != nil {
+		return err
+	}
+
+	args := []string{"commit", "--allow-empty"}
+	for _, msg := range messages {
+		args = append(args, "-m", msg)
+	}
+	return execute("creating commit", args...)
+}
+
+// CommitFiles creates a new commit with the given message paragraphs,
+// the commit includes the changes to the given paths only.
+func (git *git) CommitFiles(ctx context.Context, paths []string, messages []string) error {
+	args := []string{"commit"}
+	for _, msg := range messages {
+		args = append(args, "-m", msg)
+	}
+	args = append(append(args, "--"), paths...)
+	return execute("creating commit", args...)
+}
+
+// RemoteTags returns the name of every tag in the given remote.
+func (git *git) RemoteTags(ctx context.Context, remote string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, NetworkTimeout)
+	defer cancel()
+	cmd := command(ctx, "ls-remote", "--tags", "--refs", remote)
+	klog.V(2).InfoS("listing remote tags", "command", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+
+	tags := make([]string, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		tags = append(tags, strings.TrimPrefix(fields[1], "refs/tags/"))
+	}
+	return tags, nil
+}
+
+// ResolveSHA expands the given, possibly abbreviated, SHA to the full
+// object ID of a commit. It fails if no commit matches the prefix, or
+// if more than one commit does.
+func (git *git) ResolveSHA(ctx context.Context, sha string) (string, error) {
+	sha = strings.ToLower(strings.TrimSpace(sha))
+	if !isHex(sha) {
+		return "", fmt.Errorf("not a valid SHA: %q", sha)
+	}
+	if len(sha) == len(plumbing.ZeroHash)*2 {
+		if _, err := git.repository.CommitObject(plumbing.NewHash(sha)); err != nil {
+			return "", fmt.Errorf("commit %s not found - %w", sha, err)
+		}
+		return sha, nil
+	}
+
+	// go-git does not tell us whether a prefix is ambiguous, git does,
+	// it lists every object that matches the prefix.
+	cmd := command(ctx, "rev-parse", "--disambiguate="+sha)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+
+	matches := make([]string, 0)
+	for _, candidate := range strings.Fields(string(out)) {
+		// we are only interested in commit objects
+		if _, err := git.repository.CommitObject(plumbing.NewHash(candidate)); err != nil {
+			continue
+		}
+		matches = append(matches, candidate)
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("commit %s not found", sha)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("commit %s is ambiguous, candidates: %v", sha, matches)
+	}
+}
+
+// resolve returns the hash of the given revision, a revision that
+// looks like a SHA is resolved with ResolveSHA, so we never build
+// a hash out of an abbreviated SHA.
+func (git *git) resolve(ctx context.Context, revision string) (plumbing.Hash, error) {
+	if isHex(revision) {
+		full, err := git.ResolveSHA(ctx, revision)
+		if err == nil {
+			return plumbing.NewHash(full), nil
+		}
+		// it may still be a branch name that looks like hex
+		if _, refErr := git.repository.Reference(plumbing.NewBranchReferenceName(revision), true); refErr != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	hash, err := git.repository.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s - %w", revision, err)
+	}
+	return *hash, nil
+}
+
+func isHex(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Commit returns the commit object the given revision resolves to.
+func (git *git) Commit(ctx context.Context, sha string) (*gitv5object.Commit, error) {
+	hash, err := git.resolve(ctx, sha)
+	if err != nil {
+		return nil, err
+	}
+	return git.repository.CommitObject(hash)
+}
+
+// CommitMessage returns the full message of the given commit.
+func (git *git) CommitMessage(ctx context.Context, sha string) (string, error) {
+	commit, err := git.Commit(ctx, sha)
+	if err != nil {
+		return "", err
+	}
+	return commit.Message, nil
+}
+
+// ReadFile returns the content of the file at the given path, as it
+// is in the tree of the given revision.
+func (git *git) ReadFile(ctx context.Context, revision, path string) ([]byte, error) {
+	commit, err := git.Commit(ctx, revision)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s at %s - %w", path, revision, err)
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s - %w", path, revision, err)
+	}
+	return []byte(contents), nil
+}
+
+// ChangedFiles returns the paths touched by the given commit, compared
+// to its first parent. Both the old and the new path of a rename are
+// included.
+func (git *git) ChangedFiles(ctx context.Context, sha string) ([]string, error) {
+	commit, err := git.Commit(ctx, sha)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	parentTree := &gitv5object.Tree{}
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := gitv5object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s with its parent - %w", sha, err)
+	}
+
+	files := make([]string, 0, len(changes))
+	seen := map[string]struct{}{}
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if _, ok := seen[name]; ok || len(name) == 0 {
+				continue
+			}
+			seen[name] = struct{}{}
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+func (git *git) Head(ctx context.Context) (*gitv5object.Commit, error) {
 	reference, err := git.repository.Head()
 	if err != nil {
 		return nil, err
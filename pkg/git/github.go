@@ -6,15 +6,28 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v43/github"
 	"golang.org/x/oauth2"
 )
 
 type GitHub interface {
-	IsPRMerged(prURL string) (bool, error)
+	PullRequest(ctx context.Context, prURL string) (*PullRequest, error)
+	IsCommitIncludedIn(ctx context.Context, repo, sha, ref string) (bool, error)
 }
 
+// PullRequest is the state of a GitHub pull request, MergeCommitSHA
+// is set once the PR is merged.
+type PullRequest struct {
+	Merged         bool
+	MergeCommitSHA string
+}
+
+// NetworkTimeout bounds each call to a remote, ie. a GitHub API call,
+// or listing the tags of a git remote.
+var NetworkTimeout = time.Minute
+
 func NewGitHubClient() (GitHub, error) {
 	const tokenKey = "GITHUB_AUTH_TOKEN"
 	token := os.Getenv(tokenKey)
@@ -24,6 +37,7 @@ func NewGitHubClient() (GitHub, error) {
 
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
 	tc := oauth2.NewClient(context.Background(), ts)
+	tc.Timeout = NetworkTimeout
 	client := github.NewClient(tc)
 
 	return &githubAdapter{client: client}, nil
@@ -33,18 +47,43 @@ type githubAdapter struct {
 	client *github.Client
 }
 
-func (g *githubAdapter) IsPRMerged(prURL string) (bool, error) {
+func (g *githubAdapter) PullRequest(ctx context.Context, prURL string) (*PullRequest, error) {
 	owner, repo, number, err := extract(prURL)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	merged, _, err := g.client.PullRequests.IsMerged(context.Background(), owner, repo, number)
+	ctx, cancel := context.WithTimeout(ctx, NetworkTimeout)
+	defer cancel()
+	pr, _, err := g.client.PullRequests.Get(ctx, owner, repo, number)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return merged, nil
+	return &PullRequest{Merged: pr.GetMerged(), MergeCommitSHA: pr.GetMergeCommitSHA()}, nil
+}
+
+// IsCommitIncludedIn returns true if the given commit is reachable from
+// the given ref, repo is the GitHub repository of both, ie. 'kubernetes/utils'.
+func (g *githubAdapter) IsCommitIncludedIn(ctx context.Context, repo, sha, ref string) (bool, error) {
+	split := strings.Split(repo, "/")
+	if len(split) != 2 || len(split[0]) == 0 || len(split[1]) == 0 {
+		return false, fmt.Errorf("invalid github repository: %s", repo)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, NetworkTimeout)
+	defer cancel()
+	comparison, _, err := g.client.Repositories.CompareCommits(ctx, split[0], split[1], sha, ref, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return false, fmt.Errorf("failed to compare %s...%s in %s - %w", sha, ref, repo, err)
+	}
+
+	// ref either is the commit, or is ahead of it
+	switch comparison.GetStatus() {
+	case "identical", "ahead":
+		return true, nil
+	}
+	return false, nil
 }
 
 func extract(prURL string) (string, string, int, error) {
@@ -57,10 +96,11 @@ func extract(prURL string) (string, string, int, error) {
 	if split[lastIdx-1] != "pull" {
 		return "", "", 0, fmt.Errorf("invalid github PR URL: %s", prURL)
 	}
-	if split[lastIdx-2] != "kubernetes" {
+	if split[lastIdx-4] != "github.com" {
 		return "", "", 0, fmt.Errorf("invalid github PR URL: %s", prURL)
 	}
-	if split[lastIdx-3] != "kubernetes" {
+	owner, repo := split[lastIdx-3], split[lastIdx-2]
+	if len(owner) == 0 || len(repo) == 0 {
 		return "", "", 0, fmt.Errorf("invalid github PR URL: %s", prURL)
 	}
 
@@ -69,5 +109,5 @@ func extract(prURL string) (string, string, int, error) {
 		return "", "", 0, fmt.Errorf("invalid PR number: %s", split[lastIdx])
 	}
 
-	return "kubernetes", "kubernetes", number, nil
+	return owner, repo, number, nil
 }
@@ -1,22 +1,26 @@
 package git
 
 import (
+	"context"
 	"fmt"
-	"k8s.io/klog/v2"
 	"os"
+
+	"github.com/tkashem/rebase/pkg/target"
+	"k8s.io/klog/v2"
 )
 
 type Accessor struct {
 	Git    Git
 	GitHub GitHub
 
-	Target          string
+	Target          *target.Target
 	Marker          string
 	MetadataSource  string
 	StopAtCommitSHA string
 }
 
-func Initialize(target string) (*Accessor, error) {
+// OpenWorkingDir opens the git repository in the current working directory.
+func OpenWorkingDir() (Git, error) {
 	workingDir, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get working directory: %w", err)
@@ -29,9 +33,17 @@ func Initialize(target string) (*Accessor, error) {
 	}
 
 	klog.InfoS("opened gitAPI repository successfully", "working-directory", workingDir)
-	klog.InfoS("rebase target", "version", target)
+	return gitAPI, nil
+}
+
+func Initialize(ctx context.Context, t *target.Target) (*Accessor, error) {
+	gitAPI, err := OpenWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+	klog.InfoS("rebase target", "version", t.String())
 
-	if err := gitAPI.CheckRemotes(); err != nil {
+	if err := gitAPI.CheckRemotes(ctx); err != nil {
 		return nil, fmt.Errorf("git repo not setup properly: %v", err)
 	}
 
@@ -40,11 +52,11 @@ func Initialize(target string) (*Accessor, error) {
 		return nil, fmt.Errorf("failed to create githubAPI client - %w", err)
 	}
 
-	marker := fmt.Sprintf("openshift-rebase(%s):marker", target)
+	marker := t.Marker()
 
 	// let's find the rebase marker
 	klog.InfoS("looking for rebase marker", "pattern", marker)
-	stopAtCommit, err := gitAPI.FindRebaseMarkerCommit("", marker)
+	stopAtCommit, err := gitAPI.FindRebaseMarkerCommit(ctx, "", marker)
 	if err != nil {
 		return nil, fmt.Errorf("rebase marker not found, this branch is not properly setup for rebase - %w", err)
 	}
@@ -53,9 +65,9 @@ func Initialize(target string) (*Accessor, error) {
 	return &Accessor{
 		Git:             gitAPI,
 		GitHub:          githubAPI,
-		Target:          target,
+		Target:          t,
 		Marker:          marker,
-		MetadataSource:  fmt.Sprintf("openshift-rebase(%s):source", target),
+		MetadataSource:  t.Metadata("source"),
 		StopAtCommitSHA: stopAtCommit.Hash.String(),
 	}, nil
 }
@@ -0,0 +1,25 @@
+package git
+
+import (
+	"bufio"
+	"strings"
+)
+
+// Metadata returns the value of the given rebase metadata key recorded
+// in the commit message, ie. the carry SHA of 'openshift-rebase(v1.24):source',
+// or an empty string if the message does not have it.
+func Metadata(message, key string) string {
+	scanner := bufio.NewScanner(strings.NewReader(message))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, key+"=") {
+			return strings.TrimSpace(strings.TrimPrefix(line, key+"="))
+		}
+	}
+	return ""
+}
+
+// Subject returns the first line of the commit message.
+func Subject(message string) string {
+	return strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// VendoredRef returns the git ref of the given GitHub repository
+// (owner/repo) as it is vendored by the go.mod file. The ref is either
+// a tag or a commit SHA derived from a pseudo-version. local is set if
+// the module is replaced by a directory in the tree, ie. a staging repo.
+func VendoredRef(gomod []byte, repo string) (ref string, local bool, err error) {
+	f, err := modfile.Parse("go.mod", gomod, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to parse go.mod - %w", err)
+	}
+
+	for _, require := range f.Require {
+		version := require.Mod
+		for _, replace := range f.Replace {
+			if replace.Old.Path == version.Path && (len(replace.Old.Version) == 0 || replace.Old.Version == version.Version) {
+				version = replace.New
+			}
+		}
+
+		if modfile.IsDirectoryPath(version.Path) {
+			if ModuleRepository(require.Mod.Path) == repo {
+				return "", true, nil
+			}
+			continue
+		}
+		if ModuleRepository(version.Path) != repo {
+			continue
+		}
+
+		v := strings.TrimSuffix(version.Version, "+incompatible")
+		if module.IsPseudoVersion(v) {
+			rev, err := module.PseudoVersionRev(v)
+			if err != nil {
+				return "", false, fmt.Errorf("invalid pseudo-version %s for %s - %w", v, version.Path, err)
+			}
+			return rev, false, nil
+		}
+		return v, false, nil
+	}
+
+	return "", false, fmt.Errorf("repository %s is not a dependency", repo)
+}
+
+// ModuleRepository maps a module path to the GitHub owner/repo it is
+// hosted in, it returns an empty string if the module is not hosted
+// on GitHub.
+func ModuleRepository(path string) string {
+	split := strings.Split(path, "/")
+	switch {
+	case split[0] == "github.com" && len(split) >= 3:
+		return split[1] + "/" + split[2]
+	case split[0] == "k8s.io" && len(split) >= 2:
+		return "kubernetes/" + split[1]
+	case split[0] == "sigs.k8s.io" && len(split) >= 2:
+		return "kubernetes-sigs/" + split[1]
+	}
+	return ""
+}
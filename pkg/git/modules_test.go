@@ -0,0 +1,53 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestVendoredRef(t *testing.T) {
+	gomod := []byte(`module k8s.io/kubernetes
+
+go 1.18
+
+require (
+	github.com/google/cadvisor v0.44.0
+	k8s.io/apimachinery v0.0.0
+	k8s.io/kube-openapi v0.0.0-20220328201542-3ee0da9b0b42
+	sigs.k8s.io/structured-merge-diff/v4 v4.2.1
+)
+
+replace (
+	github.com/google/cadvisor => github.com/openshift/google-cadvisor v0.33.2-0.20220412201316-6e14aa6f2a06
+	k8s.io/apimachinery => ./staging/src/k8s.io/apimachinery
+)
+`)
+
+	tests := []struct {
+		repo     string
+		ref      string
+		local    bool
+		hasError bool
+	}{
+		{repo: "kubernetes/kube-openapi", ref: "3ee0da9b0b42"},
+		{repo: "kubernetes-sigs/structured-merge-diff", ref: "v4.2.1"},
+		{repo: "kubernetes/apimachinery", local: true},
+		{repo: "openshift/google-cadvisor", ref: "6e14aa6f2a06"},
+		{repo: "google/cadvisor", hasError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.repo, func(t *testing.T) {
+			ref, local, err := VendoredRef(gomod, test.repo)
+			switch {
+			case test.hasError:
+				if err == nil {
+					t.Errorf("Expected an error, but got ref: %s", ref)
+				}
+			case err != nil:
+				t.Errorf("Expected no error, but got: %v", err)
+			case ref != test.ref || local != test.local:
+				t.Errorf("Expected ref: %q local: %t, but got ref: %q local: %t", test.ref, test.local, ref, local)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// LogPaths returns the non-merge commits in the given range that touch
+// any of the given paths, newest first, at most max of them.
+func (git *git) LogPaths(ctx context.Context, revisionRange string, paths []string, max int) ([]LogEntry, error) {
+	args := []string{"log", "--no-merges", "--max-count=" + strconv.Itoa(max), "--format=%H%x09%s", revisionRange, "--"}
+	cmd := command(ctx, append(args, paths...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+	return parseLogEntries(out), nil
+}
+
+// Patch returns the diff the given commit introduces to the given
+// paths, all paths if none is given.
+func (git *git) Patch(ctx context.Context, sha string, paths []string) (string, error) {
+	args := []string{"show", "--format=", "--no-color", "--no-ext-diff", sha, "--"}
+	cmd := command(ctx, append(args, paths...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+	return string(out), nil
+}
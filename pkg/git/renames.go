@@ -0,0 +1,93 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// Moves are the paths upstream renamed, or deleted, between two revisions.
+type Moves struct {
+	// Renamed maps the old path to the new path
+	Renamed map[string]string
+	Deleted map[string]bool
+}
+
+// Moves returns the files renamed or deleted between the given revisions.
+func (git *git) Moves(ctx context.Context, from, to string) (*Moves, error) {
+	cmd := command(ctx, "diff", "--name-status", "-M", "--diff-filter=RD", "-z", from, to)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+
+	moves := &Moves{Renamed: map[string]string{}, Deleted: map[string]bool{}}
+	// with -z, each status is followed by one path, two for a rename
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	for i := 0; i < len(fields); i++ {
+		status := fields[i]
+		switch {
+		case strings.HasPrefix(status, "R") && i+2 < len(fields):
+			moves.Renamed[fields[i+1]] = fields[i+2]
+			i += 2
+		case strings.HasPrefix(status, "D") && i+1 < len(fields):
+			moves.Deleted[fields[i+1]] = true
+			i++
+		}
+	}
+	return moves, nil
+}
+
+// PickRewritten applies the given commit with its paths rewritten, the
+// author and the commit message are retained.
+func (git *git) PickRewritten(ctx context.Context, sha string, renamed map[string]string) error {
+	cmd := command(ctx, "format-patch", "-1", "--stdout", "--no-renames", sha)
+	patch, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+
+	am := change("am", "--3way", "--keep-cr")
+	am.Stdin = bytes.NewReader(rewritePatch(patch, renamed))
+	if err := run(fmt.Sprintf("executing rename-aware pick of %s", sha), am); err != nil {
+		if abortErr := change("am", "--abort").Run(); abortErr != nil {
+			klog.ErrorS(abortErr, "failed to abort git am")
+		}
+		return err
+	}
+	return nil
+}
+
+// rewritePatch rewrites the paths in the headers of the given patch.
+func rewritePatch(patch []byte, renamed map[string]string) []byte {
+	lines := strings.SplitAfter(string(patch), "\n")
+	header := false
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git a/"):
+			header = true
+			// diff --git a/{path} b/{path}
+			split := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(line, "diff --git a/"), "\n"), " b/", 2)
+			if len(split) == 2 {
+				lines[i] = fmt.Sprintf("diff --git a/%s b/%s\n", rename(renamed, split[0]), rename(renamed, split[1]))
+			}
+		case header && strings.HasPrefix(line, "--- a/"):
+			lines[i] = "--- a/" + rename(renamed, strings.TrimSuffix(strings.TrimPrefix(line, "--- a/"), "\n")) + "\n"
+		case header && strings.HasPrefix(line, "+++ b/"):
+			lines[i] = "+++ b/" + rename(renamed, strings.TrimSuffix(strings.TrimPrefix(line, "+++ b/"), "\n")) + "\n"
+		case strings.HasPrefix(line, "@@"):
+			header = false
+		}
+	}
+	return []byte(strings.Join(lines, ""))
+}
+
+func rename(renamed map[string]string, path string) string {
+	if to, ok := renamed[path]; ok {
+		return to
+	}
+	return path
+}
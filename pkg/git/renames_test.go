@@ -0,0 +1,59 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestRewritePatch(t *testing.T) {
+	patch := `From 8bd488b66eb Mon Sep 17 00:00:00 2001
+Subject: [PATCH] UPSTREAM: <carry>: kubelet: foo
+
+---
+ pkg/kubelet/foo.go | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/pkg/kubelet/foo.go b/pkg/kubelet/foo.go
+index 1111111..2222222 100644
+--- a/pkg/kubelet/foo.go
++++ b/pkg/kubelet/foo.go
+@@ -1,3 +1,3 @@
+ package kubelet
+--- a/pkg/kubelet/foo.go
++--- b/pkg/kubelet/foo.go
+diff --git a/pkg/kubelet/bar.go b/pkg/kubelet/bar.go
+index 1111111..2222222 100644
+--- a/pkg/kubelet/bar.go
++++ b/pkg/kubelet/bar.go
+@@ -1 +1 @@
+-a
++b
+`
+	expected := `From 8bd488b66eb Mon Sep 17 00:00:00 2001
+Subject: [PATCH] UPSTREAM: <carry>: kubelet: foo
+
+---
+ pkg/kubelet/foo.go | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/pkg/kubelet/cm/foo.go b/pkg/kubelet/cm/foo.go
+index 1111111..2222222 100644
+--- a/pkg/kubelet/cm/foo.go
++++ b/pkg/kubelet/cm/foo.go
+@@ -1,3 +1,3 @@
+ package kubelet
+--- a/pkg/kubelet/foo.go
++--- b/pkg/kubelet/foo.go
+diff --git a/pkg/kubelet/bar.go b/pkg/kubelet/bar.go
+index 1111111..2222222 100644
+--- a/pkg/kubelet/bar.go
++++ b/pkg/kubelet/bar.go
+@@ -1 +1 @@
+-a
++b
+`
+
+	got := string(rewritePatch([]byte(patch), map[string]string{"pkg/kubelet/foo.go": "pkg/kubelet/cm/foo.go"}))
+	if got != expected {
+		t.Errorf("Expected rewritten patch:\n%s\nbut got:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,131 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Operation is a git operation that is in progress in the working tree.
+type Operation string
+
+const (
+	CherryPickInProgress Operation = "cherry-pick"
+	RevertInProgress     Operation = "revert"
+	MergeInProgress      Operation = "merge"
+	RebaseInProgress     Operation = "rebase"
+	AmInProgress         Operation = "am"
+	BisectInProgress     Operation = "bisect"
+)
+
+// Workspace is the state of the working tree and the index.
+type Workspace struct {
+	// GitDir is the absolute path of the git directory of the working
+	// tree, for a linked worktree it is the worktree's own directory.
+	GitDir string
+
+	// Operations are the git operations in progress
+	Operations []Operation
+	// CherryPickHead is the commit being cherry-picked, if any
+	CherryPickHead string
+
+	// Changed are the tracked paths with changes, staged or not,
+	// untracked files are ignored.
+	Changed []string
+	// Unmerged are the paths with unresolved conflicts
+	Unmerged []string
+
+	// IndexLocked is true if another git process holds the index lock
+	IndexLocked bool
+}
+
+// Workspace inspects the git directory and the index of the working tree.
+func (git *git) Workspace(ctx context.Context) (*Workspace, error) {
+	cmd := command(ctx, "rev-parse", "--absolute-git-dir")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+	ws := &Workspace{GitDir: strings.TrimSpace(string(out))}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(ws.GitDir, name))
+		return err == nil
+	}
+	for _, marker := range []struct {
+		name      string
+		operation Operation
+	}{
+		{name: "CHERRY_PICK_HEAD", operation: CherryPickInProgress},
+		{name: "REVERT_HEAD", operation: RevertInProgress},
+		{name: "MERGE_HEAD", operation: MergeInProgress},
+		{name: "rebase-merge", operation: RebaseInProgress},
+		{name: "BISECT_LOG", operation: BisectInProgress},
+	} {
+		if exists(marker.name) {
+			ws.Operations = append(ws.Operations, marker.operation)
+		}
+	}
+	// rebase-apply is shared by 'git am' and the apply backend of rebase
+	if exists("rebase-apply") {
+		if exists(filepath.Join("rebase-apply", "applying")) {
+			ws.Operations = append(ws.Operations, AmInProgress)
+		} else {
+			ws.Operations = append(ws.Operations, RebaseInProgress)
+		}
+	}
+	ws.IndexLocked = exists("index.lock")
+
+	if exists("CHERRY_PICK_HEAD") {
+		if ws.CherryPickHead, err = git.CherryPickHead(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd = command(ctx, "status", "--porcelain", "-z", "--untracked-files=no")
+	if out, err = cmd.Output(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+	ws.Changed, ws.Unmerged = parseStatus(out)
+	return ws, nil
+}
+
+// parseStatus parses the output of 'git status --porcelain -z', it
+// returns the changed paths, and the paths that are unmerged.
+func parseStatus(out []byte) ([]string, []string) {
+	changed, unmerged := make([]string, 0), make([]string, 0)
+	entries := strings.Split(string(out), "\x00")
+	for i := 0; i < len(entries); i++ {
+		// XY PATH, a rename or a copy is followed by the original path
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		xy, path := entry[:2], entry[3:]
+		if xy[0] == 'R' || xy[0] == 'C' {
+			i++
+		}
+		changed = append(changed, path)
+		if strings.Contains(xy, "U") || xy == "AA" || xy == "DD" {
+			unmerged = append(unmerged, path)
+		}
+	}
+	return changed, unmerged
+}
+
+// ContinueCherryPick commits the resolution of a cherry-pick that
+// stopped on a conflict, the message of the picked commit is kept.
+func (git *git) ContinueCherryPick(ctx context.Context) error {
+	cmd := change("cherry-pick", "--continue")
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	return run("continuing cherry-pick", cmd)
+}
+
+// CommitEmptyCherryPick commits the cherry-pick in progress that stopped
+// because its change is already in HEAD, the commit is empty, and it
+// keeps the message and the author of the picked commit.
+func (git *git) CommitEmptyCherryPick(ctx context.Context) error {
+	return execute("committing empty cherry-pick", "commit", "--allow-empty", "--no-edit")
+}
@@ -0,0 +1,18 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	out := "M  pkg/a.go\x00 M pkg/b.go\x00UU pkg/c.go\x00AA pkg/d.go\x00R  pkg/new.go\x00pkg/old.go\x00"
+
+	changed, unmerged := parseStatus([]byte(out))
+	if expected := []string{"pkg/a.go", "pkg/b.go", "pkg/c.go", "pkg/d.go", "pkg/new.go"}; !reflect.DeepEqual(expected, changed) {
+		t.Errorf("Expected changed: %v, but got: %v", expected, changed)
+	}
+	if expected := []string{"pkg/c.go", "pkg/d.go"}; !reflect.DeepEqual(expected, unmerged) {
+		t.Errorf("Expected unmerged: %v, but got: %v", expected, unmerged)
+	}
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// Worktree is a working tree of the repository, the main one or a
+// linked one.
+type Worktree struct {
+	Path string
+	HEAD string
+	// Branch is the short name of the branch checked out, it is empty
+	// if HEAD is detached.
+	Branch string
+}
+
+// EnterWorktree makes the linked worktree at the given path the working
+// directory of the process, so every git operation, and every command
+// that runs in the repository, runs there and leaves the checkout of
+// the user untouched. The worktree is reused if it exists, otherwise it
+// is created with the given branch checked out, the branch is created
+// at HEAD if it does not exist. A branch is required to create it, as
+// the commits made on a detached HEAD are easily lost.
+func EnterWorktree(ctx context.Context, path, branch string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %q - %w", path, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	cmd := command(ctx, "worktree", "list", "--porcelain")
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", cmd.String(), err)
+	}
+
+	var existing *Worktree
+	for _, worktree := range parseWorktrees(string(out)) {
+		if worktree.Path == abs {
+			existing = &worktree
+			break
+		}
+	}
+	switch {
+	case existing != nil && len(branch) > 0 && existing.Branch != branch:
+		return fmt.Errorf("worktree %s has %q checked out, not %s", abs, existing.Branch, branch)
+	case existing != nil:
+		klog.InfoS("reusing worktree", "worktree", abs, "branch", existing.Branch, "head", existing.HEAD)
+	case len(branch) == 0:
+		return fmt.Errorf("worktree %s does not exist, a branch is required to create it", abs)
+	default:
+		if _, err := os.Stat(abs); err == nil {
+			return fmt.Errorf("%s exists, and it is not a worktree of this repository", abs)
+		}
+		args := []string{"worktree", "add", abs, branch}
+		if exists := command(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil; !exists {
+			args = []string{"worktree", "add", "-b", branch, abs, "HEAD"}
+		}
+		if err := execute("adding worktree", args...); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Chdir(abs); err != nil {
+		return fmt.Errorf("failed to change the working directory to %s - %w", abs, err)
+	}
+	return nil
+}
+
+// parseWorktrees parses the output of 'git worktree list --porcelain'.
+func parseWorktrees(out string) []Worktree {
+	worktrees := make([]Worktree, 0)
+	var current *Worktree
+	for _, line := range strings.Split(out, "\n") {
+		key, value := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, Worktree{Path: value})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.HEAD = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		}
+	}
+	return worktrees
+}
+
+// AddWorktree creates a linked worktree at the given path, with HEAD
+// detached at the given commit.
+func (git *git) AddWorktree(ctx context.Context, path, commitish string) error {
+	return execute("adding worktree", "worktree", "add", "--detach", path, commitish)
+}
+
+// RemoveWorktree removes the linked worktree at the given path, along
+// with any change in it.
+func (git *git) RemoveWorktree(ctx context.Context, path string) error {
+	return execute("removing worktree", "worktree", "remove", "--force", path)
+}
+
+// CheckoutDetached checks out the given commit in the linked worktree
+// at the given path, the working tree of the repository is untouched.
+func (git *git) CheckoutDetached(ctx context.Context, path, sha string) error {
+	cmd := change("checkout", "--quiet", "--force", "--detach", sha)
+	cmd.Dir = path
+
+	klog.V(2).InfoS("checking out in worktree", "command", cmd.String(), "worktree", path)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git checkout of %s in %s failed: %w\n%s", sha, path, err, out)
+	}
+	return nil
+}
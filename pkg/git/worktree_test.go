@@ -0,0 +1,31 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWorktrees(t *testing.T) {
+	out := `worktree /home/user/go/src/k8s.io/kubernetes
+HEAD 6e9f2a3a8d1b04c1f7c6b6a4e2cb1b0d0d7b3b11
+branch refs/heads/master
+
+worktree /home/user/rebase/v1.24
+HEAD 0a1b2c3d4e5f60718293a4b5c6d7e8f901234567
+branch refs/heads/rebase-v1.24
+
+worktree /tmp/rebase-bisect-1234/worktree
+HEAD 89abcdef0123456789abcdef0123456789abcdef
+detached
+
+`
+	expected := []Worktree{
+		{Path: "/home/user/go/src/k8s.io/kubernetes", HEAD: "6e9f2a3a8d1b04c1f7c6b6a4e2cb1b0d0d7b3b11", Branch: "master"},
+		{Path: "/home/user/rebase/v1.24", HEAD: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", Branch: "rebase-v1.24"},
+		{Path: "/tmp/rebase-bisect-1234/worktree", HEAD: "89abcdef0123456789abcdef0123456789abcdef"},
+	}
+
+	if got := parseWorktrees(out); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected worktrees: %v, but got: %v", expected, got)
+	}
+}
@@ -0,0 +1,175 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"k8s.io/klog/v2"
+)
+
+func New(reader carry.CommitReader, from, to *target.Target, overridesFrom, overridesTo string) (*cmd, error) {
+	gitAPI, err := git.OpenWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &cmd{
+		reader:        reader,
+		git:           gitAPI,
+		from:          from.String(),
+		to:            to.String(),
+		metadata:      from.Metadata("source"),
+		overridesFrom: overridesFrom,
+		overridesTo:   overridesTo,
+	}, nil
+}
+
+type cmd struct {
+	reader                     carry.CommitReader
+	git                        git.Git
+	from, to, metadata         string
+	overridesFrom, overridesTo string
+}
+
+// flagged is a carry commit, or an override, that could not be
+// migrated and needs a human decision.
+type flagged struct {
+	sha     string
+	message string
+	reason  string
+}
+
+func (f flagged) String() string { return fmt.Sprintf("%s - %s: %s", f.sha, f.reason, f.message) }
+
+func (c *cmd) Run(ctx context.Context) error {
+	klog.InfoS("migrate in progress", "from", c.from, "to", c.to, "metadata", c.metadata,
+		"overrides-from", c.overridesFrom, "overrides-to", c.overridesTo)
+
+	previous := make([]carry.Override, 0)
+	if len(c.overridesFrom) > 0 {
+		overrides, err := carry.LoadOverrides(c.overridesFrom)
+		if err != nil {
+			return err
+		}
+		previous = overrides
+	}
+
+	// these are the carry commits on openshift/master we want to pick
+	// in the next release, they have been picked during the previous
+	// rebase, so each of them should have the source metadata.
+	carries, err := c.reader.Read(ctx)
+	if err != nil {
+		return err
+	}
+
+	migrated, flags, err := c.migrate(ctx, previous, carries)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.overridesTo), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %q - %w", c.overridesTo, err)
+	}
+	file, err := os.Create(c.overridesTo)
+	if err != nil {
+		return fmt.Errorf("failed to create %q - %w", c.overridesTo, err)
+	}
+	defer file.Close()
+
+	if err := carry.WriteOverrides(file, migrated); err != nil {
+		return fmt.Errorf("failed to write overrides to %q - %w", c.overridesTo, err)
+	}
+	if len(flags) > 0 {
+		w := bufio.NewWriter(file)
+		fmt.Fprintf(w, "\n# the following need a human decision, migrate from %s could not map them\n", c.from)
+		for _, f := range flags {
+			fmt.Fprintf(w, "# %s\n", f.String())
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to write overrides to %q - %w", c.overridesTo, err)
+		}
+	}
+
+	klog.Infof("stats: carries(%d), overrides(%d->%d), flagged(%d)", len(carries), len(previous), len(migrated), len(flags))
+	for _, f := range flags {
+		klog.Infof("needs decision: %s", f.String())
+	}
+	return nil
+}
+
+func (c *cmd) migrate(ctx context.Context, previous []carry.Override, carries []*carry.CommitSummary) ([]carry.Override, []flagged, error) {
+	// the override file, and the source metadata, may not use SHAs of
+	// the same length, both are resolved to the full object ID
+	bySHA := map[string]*carry.Override{}
+	patterns := make([]carry.Override, 0)
+	flags := make([]flagged, 0)
+	used := map[string]bool{}
+	for i := range previous {
+		if len(previous[i].SHA) == 0 {
+			patterns = append(patterns, previous[i])
+			continue
+		}
+		full, err := c.git.ResolveSHA(ctx, previous[i].SHA)
+		if err != nil {
+			used[previous[i].SHA] = true
+			flags = append(flags, flagged{sha: previous[i].SHA, message: strings.Join(previous[i].Comments, " "), reason: fmt.Sprintf("override from %s does not resolve to a commit: %v", c.from, err)})
+			continue
+		}
+		bySHA[full] = &previous[i]
+	}
+
+	migrated := make([]carry.Override, 0)
+	for _, summary := range carries {
+		commit, err := c.git.Commit(ctx, summary.SHA)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find carry commit %s - %w", summary.SHA, err)
+		}
+
+		source := git.Metadata(commit.Message, c.metadata)
+		if len(source) == 0 {
+			flags = append(flags, flagged{sha: summary.ShortSHA(), message: summary.MessageWithPrefix, reason: fmt.Sprintf("no %s metadata", c.metadata)})
+			continue
+		}
+		full, err := c.git.ResolveSHA(ctx, source)
+		if err != nil {
+			flags = append(flags, flagged{sha: summary.ShortSHA(), message: summary.MessageWithPrefix, reason: fmt.Sprintf("%s metadata does not resolve to a commit: %v", c.metadata, err)})
+			continue
+		}
+
+		override, ok := bySHA[full]
+		if !ok {
+			klog.V(2).InfoS("carry has no override", "sha", summary.SHA, "source", source)
+			continue
+		}
+		used[override.SHA] = true
+
+		klog.Infof("migrate(%s): %s->%s\t%s", override.Do, override.SHA, summary.ShortSHA(), summary.MessageWithPrefix)
+		next := *override
+		next.SHA = summary.ShortSHA()
+		next.Comments = append(append([]string{}, override.Comments...), fmt.Sprintf("migrated from %s: %s", c.from, override.SHA))
+		migrated = append(migrated, next)
+	}
+
+	for i := range previous {
+		if len(previous[i].SHA) == 0 || used[previous[i].SHA] {
+			continue
+		}
+		// a carry dropped in the previous rebase never made it to
+		// openshift/master, so the override has served its purpose.
+		if previous[i].Do == "drop" {
+			klog.Infof("retired(%s): %s - %s", previous[i].Do, previous[i].SHA, strings.Join(previous[i].Comments, " "))
+			continue
+		}
+		flags = append(flags, flagged{sha: previous[i].SHA, message: strings.Join(previous[i].Comments, " "), reason: fmt.Sprintf("override from %s does not map to any carry", c.from)})
+	}
+
+	// patterns do not depend on SHA, we carry them forward as is
+	return append(migrated, patterns...), flags, nil
+}
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+)
+
+const (
+	metadata = "openshift-rebase(v1.23):source"
+
+	sourceA = "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678"
+	sourceB = "b1b2c3d4e5f60718293a4b5c6d7e8f9012345678"
+	sourceC = "a1b2c3d4e5ff0718293a4b5c6d7e8f9012345678"
+)
+
+// fakeGit implements the subset of git.Git the tests exercise, objects
+// holds the full SHA of every known commit.
+type fakeGit struct {
+	git.Git
+	objects  []string
+	messages map[string]string
+}
+
+func (f *fakeGit) Commit(_ context.Context, sha string) (*gitv5object.Commit, error) {
+	return &gitv5object.Commit{Message: f.messages[sha]}, nil
+}
+
+func (f *fakeGit) ResolveSHA(_ context.Context, sha string) (string, error) {
+	matches := make([]string, 0)
+	for _, object := range f.objects {
+		if strings.HasPrefix(object, sha) {
+			matches = append(matches, object)
+		}
+	}
+	if len(matches) != 1 {
+		return "", fmt.Errorf("%s matches %d commits", sha, len(matches))
+	}
+	return matches[0], nil
+}
+
+func TestMigrate(t *testing.T) {
+	carries := []*carry.CommitSummary{
+		{SHA: "1111111111111111111111111111111111111111", AbbreviatedSHA: "11111111111", MessageWithPrefix: "UPSTREAM: <carry>: a"},
+		{SHA: "2222222222222222222222222222222222222222", AbbreviatedSHA: "22222222222", MessageWithPrefix: "UPSTREAM: <carry>: b"},
+	}
+	objects := []string{sourceA, sourceB, sourceC}
+
+	tests := []struct {
+		name     string
+		previous []carry.Override
+		messages map[string]string
+		expected []string
+		flagged  []string
+	}{
+		{
+			name:     "an abbreviated override maps to the carry picked from it",
+			previous: []carry.Override{{SHA: sourceA[:12], Do: "drop"}, {SHA: sourceB[:7], Do: "carry"}},
+			messages: map[string]string{
+				carries[0].SHA: "UPSTREAM: <carry>: a\n\n" + metadata + "=" + sourceA,
+				carries[1].SHA: "UPSTREAM: <carry>: b\n\n" + metadata + "=" + sourceB[:11],
+			},
+			expected: []string{"drop:11111111111", "carry:22222222222"},
+		},
+		{
+			name:     "an ambiguous abbreviated override is flagged",
+			previous: []carry.Override{{SHA: sourceA[:11], Do: "drop"}},
+			messages: map[string]string{
+				carries[0].SHA: "UPSTREAM: <carry>: a\n\n" + metadata + "=" + sourceA,
+			},
+			expected: []string{},
+			flagged:  []string{sourceA[:11], "22222222222"},
+		},
+		{
+			name:     "a carry with no metadata is flagged",
+			previous: []carry.Override{{SHA: sourceA, Do: "drop"}},
+			messages: map[string]string{
+				carries[1].SHA: "UPSTREAM: <carry>: b\n\n" + metadata + "=" + sourceB,
+			},
+			expected: []string{},
+			flagged:  []string{"11111111111"},
+		},
+		{
+			name:     "an unused drop is retired, an unused carry is flagged",
+			previous: []carry.Override{{SHA: sourceA, Do: "drop"}, {SHA: sourceC, Do: "carry"}, {Message: "update vendor", Do: "carry"}},
+			messages: map[string]string{
+				carries[0].SHA: "UPSTREAM: <carry>: a\n\n" + metadata + "=" + sourceB,
+				carries[1].SHA: "UPSTREAM: <carry>: b\n\n" + metadata + "=" + sourceB,
+			},
+			expected: []string{"carry:"},
+			flagged:  []string{sourceC},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &cmd{git: &fakeGit{objects: objects, messages: test.messages}, from: "v1.23", metadata: metadata}
+
+			migrated, flags, err := c.migrate(context.TODO(), test.previous, carries)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			got := make([]string, 0)
+			for _, override := range migrated {
+				got = append(got, override.Do+":"+override.SHA)
+			}
+			if !reflect.DeepEqual(test.expected, got) {
+				t.Errorf("Expected overrides: %v, but got: %v", test.expected, got)
+			}
+			shas := make([]string, 0)
+			for _, f := range flags {
+				shas = append(shas, f.sha)
+			}
+			if len(test.flagged) == 0 {
+				test.flagged = []string{}
+			}
+			if !reflect.DeepEqual(test.flagged, shas) {
+				t.Errorf("Expected flagged: %v, but got: %v", test.flagged, shas)
+			}
+		})
+	}
+}
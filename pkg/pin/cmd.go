@@ -0,0 +1,70 @@
+package pin
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"k8s.io/klog/v2"
+)
+
+type editFunc func(gomod string, data []byte, pins []Pin) ([]byte, []Pin, error)
+
+func New(config *Config, unpin bool) (*cmd, error) {
+	c := &cmd{config: config, verb: "pin", edit: Apply}
+	if unpin {
+		c.verb, c.edit = "unpin", Revert
+	}
+	return c, nil
+}
+
+type cmd struct {
+	config *Config
+	verb   string
+	edit   editFunc
+}
+
+func (c *cmd) Run(_ context.Context) error {
+	klog.InfoS(c.verb+" in progress", "modules", len(c.config.Modules), "pins", len(c.config.Pins))
+
+	total, used := 0, map[string]bool{}
+	for _, gomod := range c.config.Modules {
+		data, err := os.ReadFile(gomod)
+		if err != nil {
+			return fmt.Errorf("failed to read %q - %w", gomod, err)
+		}
+		out, edited, err := c.edit(gomod, data, c.config.Pins)
+		if err != nil {
+			return err
+		}
+		if len(edited) == 0 {
+			klog.Infof("%s: %s has nothing to %s", c.verb, gomod, c.verb)
+			continue
+		}
+		if err := os.WriteFile(gomod, out, 0644); err != nil {
+			return fmt.Errorf("failed to write %q - %w", gomod, err)
+		}
+		for _, pin := range edited {
+			klog.Infof("%s: %s\t%s", c.verb, gomod, pin.String())
+			used[pin.Path] = true
+		}
+		total += len(edited)
+	}
+
+	klog.Infof("stats: modules(%d), %s(%d)", len(c.config.Modules), c.verb, total)
+	if c.verb == "pin" {
+		// a pin that no module depends on is likely a typo in the path
+		for _, pin := range c.config.Pins {
+			if !used[pin.Path] {
+				klog.Warningf("pin: %s is not applied, no module of the pin set depends on %s", pin.String(), pin.Path)
+			}
+		}
+		if total == 0 {
+			return fmt.Errorf("no pin of the pin set is applied to any of its modules, pins(%d)", len(c.config.Pins))
+		}
+	}
+	if total > 0 {
+		klog.Infof("run 'go mod tidy' in each edited module, and 'hack/update-vendor.sh' to update the vendor directory")
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package pin
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Config is the pin set of a rebase, the pins are applied to every
+// go.mod file in Modules that depends on the pinned module.
+type Config struct {
+	Modules []string `json:"modules,omitempty"`
+	Pins    []Pin    `json:"pins,omitempty"`
+}
+
+// Pin replaces a module with a fork at a given version, ie.
+//
+//	github.com/openshift/api => github.com/tkashem/api v0.0.0-20220420...
+//
+// The version must be a semantic version, or a pseudo-version, a
+// branch name is not reproducible so it is rejected. Revert drops the
+// replace directive, unless Keep is set, and requires the module at
+// the Upstream version, if specified.
+type Pin struct {
+	Path     string `json:"path,omitempty"`
+	Replace  string `json:"replace,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Upstream string `json:"upstream,omitempty"`
+	Keep     bool   `json:"keep,omitempty"`
+}
+
+func (p *Pin) String() string {
+	return fmt.Sprintf("%s => %s %s", p.Path, p.Replace, p.Version)
+}
+
+// Load reads and validates the pin set in the given file.
+func Load(fpath string) (*Config, error) {
+	file, err := os.Open(fpath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading file %q - %w", fpath, err)
+	}
+	defer file.Close()
+
+	c := &Config{}
+	if err := utilyaml.NewYAMLToJSONDecoder(file).Decode(c); err != nil {
+		return nil, fmt.Errorf("failed to decode pins from %q - %w", fpath, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid pins in %q - %w", fpath, err)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Modules) == 0 {
+		return fmt.Errorf("no go.mod file specified")
+	}
+
+	for i := range c.Pins {
+		pin := &c.Pins[i]
+		if len(pin.Path) == 0 || len(pin.Replace) == 0 || len(pin.Version) == 0 {
+			return fmt.Errorf("pin[%d] must specify path, replace and version", i)
+		}
+		if err := checkVersion(pin.Replace, pin.Version); err != nil {
+			return fmt.Errorf("pin[%d] %s - %w", i, pin.String(), err)
+		}
+		if len(pin.Upstream) > 0 {
+			if err := checkVersion(pin.Path, pin.Upstream); err != nil {
+				return fmt.Errorf("pin[%d] %s upstream - %w", i, pin.String(), err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkVersion(path, version string) error {
+	if !semver.IsValid(version) {
+		return fmt.Errorf("%q is a branch, or not a valid version, resolve it to a pseudo-version with: go list -m %s@%s", version, path, version)
+	}
+	return module.Check(path, version)
+}
+
+// Apply applies the pins to the given go.mod content, a pin is applied
+// only if the module depends on the pinned module. It returns the
+// edited content, and the pins that were applied.
+func Apply(gomod string, data []byte, pins []Pin) ([]byte, []Pin, error) {
+	f, err := modfile.Parse(gomod, data, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s - %w", gomod, err)
+	}
+
+	applied := make([]Pin, 0)
+	for _, pin := range pins {
+		if !requires(f, pin.Path) {
+			continue
+		}
+		if err := dropReplace(f, pin.Path); err != nil {
+			return nil, nil, err
+		}
+		if err := f.AddReplace(pin.Path, "", pin.Replace, pin.Version); err != nil {
+			return nil, nil, fmt.Errorf("failed to pin %s in %s - %w", pin.String(), gomod, err)
+		}
+		applied = append(applied, pin)
+	}
+
+	out, err := format(f)
+	return out, applied, err
+}
+
+// Revert reverts the pins in the given go.mod content, it returns the
+// edited content, and the pins that were reverted.
+func Revert(gomod string, data []byte, pins []Pin) ([]byte, []Pin, error) {
+	f, err := modfile.Parse(gomod, data, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s - %w", gomod, err)
+	}
+
+	reverted := make([]Pin, 0)
+	for _, pin := range pins {
+		if pin.Keep || !replaced(f, pin.Path, pin.Replace) {
+			continue
+		}
+		if err := dropReplace(f, pin.Path); err != nil {
+			return nil, nil, err
+		}
+		if len(pin.Upstream) > 0 {
+			if err := f.AddRequire(pin.Path, pin.Upstream); err != nil {
+				return nil, nil, fmt.Errorf("failed to require %s %s in %s - %w", pin.Path, pin.Upstream, gomod, err)
+			}
+		}
+		reverted = append(reverted, pin)
+	}
+
+	out, err := format(f)
+	return out, reverted, err
+}
+
+func requires(f *modfile.File, path string) bool {
+	for _, require := range f.Require {
+		if require.Mod.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func replaced(f *modfile.File, path, with string) bool {
+	for _, replace := range f.Replace {
+		if replace.Old.Path == path && replace.New.Path == with {
+			return true
+		}
+	}
+	return false
+}
+
+// dropReplace removes every replace directive of the given module,
+// regardless of the version it applies to.
+func dropReplace(f *modfile.File, path string) error {
+	olds := make([]module.Version, 0)
+	for _, replace := range f.Replace {
+		if replace.Old.Path == path {
+			olds = append(olds, replace.Old)
+		}
+	}
+	for _, old := range olds {
+		if err := f.DropReplace(old.Path, old.Version); err != nil {
+			return fmt.Errorf("failed to drop replace of %s - %w", old.Path, err)
+		}
+	}
+	return nil
+}
+
+func format(f *modfile.File) ([]byte, error) {
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		return nil, fmt.Errorf("failed to format go.mod - %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package pin
+
+import (
+	"strings"
+	"testing"
+)
+
+const gomod = `module k8s.io/kubernetes
+
+go 1.18
+
+require (
+	github.com/onsi/ginkgo v1.14.0
+	github.com/openshift/api v0.0.0-20220315184754-d7c10d0b647e
+	k8s.io/api v0.0.0
+)
+
+replace (
+	github.com/onsi/ginkgo v1.14.0 => github.com/openshift/ginkgo v4.7.0-origin.0+incompatible
+	k8s.io/api => ./staging/src/k8s.io/api
+)
+`
+
+func TestPinAndUnpin(t *testing.T) {
+	pins := []Pin{
+		{Path: "github.com/openshift/api", Replace: "github.com/tkashem/api", Version: "v0.0.0-20220420000000-641a165d1cca", Upstream: "v0.0.0-20220425000000-a2b6d3c4e5f6"},
+		{Path: "github.com/openshift/library-go", Replace: "github.com/tkashem/library-go", Version: "v0.0.0-20220420000000-607a089b3f0b"},
+		{Path: "github.com/onsi/ginkgo", Replace: "github.com/openshift/ginkgo", Version: "v4.7.0-origin.0+incompatible", Keep: true},
+	}
+
+	pinned, applied, err := Apply("go.mod", []byte(gomod), pins)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	// library-go is not a dependency of the module
+	if len(applied) != 2 {
+		t.Errorf("Expected 2 pins to apply, but got: %v", applied)
+	}
+	for _, want := range []string{
+		"github.com/openshift/api => github.com/tkashem/api v0.0.0-20220420000000-641a165d1cca",
+		"github.com/onsi/ginkgo => github.com/openshift/ginkgo v4.7.0-origin.0+incompatible",
+		"k8s.io/api => ./staging/src/k8s.io/api",
+	} {
+		if !strings.Contains(string(pinned), want) {
+			t.Errorf("Expected %q in go.mod, but got:\n%s", want, pinned)
+		}
+	}
+	if strings.Contains(string(pinned), "ginkgo v1.14.0 =>") {
+		t.Errorf("Expected the versioned replace of ginkgo to be dropped, but got:\n%s", pinned)
+	}
+
+	unpinned, reverted, err := Revert("go.mod", pinned, pins)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(reverted) != 1 {
+		t.Errorf("Expected 1 pin to revert, but got: %v", reverted)
+	}
+	for _, want := range []string{
+		"github.com/openshift/api v0.0.0-20220425000000-a2b6d3c4e5f6",
+		"github.com/onsi/ginkgo => github.com/openshift/ginkgo v4.7.0-origin.0+incompatible",
+	} {
+		if !strings.Contains(string(unpinned), want) {
+			t.Errorf("Expected %q in go.mod, but got:\n%s", want, unpinned)
+		}
+	}
+	if strings.Contains(string(unpinned), "tkashem") {
+		t.Errorf("Expected the fork to be unpinned, but got:\n%s", unpinned)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		err     bool
+	}{
+		{name: "pseudo-version", version: "v0.0.0-20220420000000-641a165d1cca"},
+		{name: "branch", version: "bump-1.24", err: true},
+		{name: "major version mismatch", version: "v2.0.0", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Config{
+				Modules: []string{"go.mod"},
+				Pins:    []Pin{{Path: "github.com/openshift/api", Replace: "github.com/tkashem/api", Version: test.version}},
+			}
+			err := c.validate()
+			if test.err != (err != nil) {
+				t.Errorf("Expected error: %t, but got: %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package recipe
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Recipe is the ordered list of manual steps that follow the carry
+// picks of a rebase, each step ends in a commit.
+type Recipe struct {
+	Steps []Step `json:"steps,omitempty"`
+}
+
+// Step edits files, runs commands, and commits the result with the
+// given message. The name identifies the step in the rebase metadata
+// of its commit, so it must be unique within a recipe. The files and
+// the commands are relative to Dir, the root of the repository if
+// not set.
+type Step struct {
+	Name     string   `json:"name,omitempty"`
+	Edits    []Edit   `json:"edits,omitempty"`
+	Dir      string   `json:"dir,omitempty"`
+	Commands []string `json:"commands,omitempty"`
+	Commit   string   `json:"commit,omitempty"`
+}
+
+// Edit replaces every match of a regular expression in a file, the
+// replacement may refer to the submatches, ie. ${1}.
+type Edit struct {
+	File    string `json:"file,omitempty"`
+	Replace string `json:"replace,omitempty"`
+	With    string `json:"with,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// Load reads and validates the recipe in the given file.
+func Load(fpath string) (*Recipe, error) {
+	file, err := os.Open(fpath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading file %q - %w", fpath, err)
+	}
+	defer file.Close()
+
+	r := &Recipe{}
+	if err := utilyaml.NewYAMLToJSONDecoder(file).Decode(r); err != nil {
+		return nil, fmt.Errorf("failed to decode recipe from %q - %w", fpath, err)
+	}
+	if err := r.compile(); err != nil {
+		return nil, fmt.Errorf("invalid recipe in %q - %w", fpath, err)
+	}
+	return r, nil
+}
+
+func (r *Recipe) compile() error {
+	if len(r.Steps) == 0 {
+		return fmt.Errorf("recipe has no step")
+	}
+
+	names := map[string]bool{}
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		switch {
+		case len(step.Name) == 0:
+			return fmt.Errorf("step[%d] does not specify a name", i)
+		case strings.ContainsAny(step.Name, " \t\n"):
+			return fmt.Errorf("step[%d] name %q must not contain white space", i, step.Name)
+		case names[step.Name]:
+			return fmt.Errorf("step[%d] name %q is not unique", i, step.Name)
+		case len(step.Commit) == 0:
+			return fmt.Errorf("step[%d] %q does not specify a commit message", i, step.Name)
+		case len(step.Edits) == 0 && len(step.Commands) == 0:
+			return fmt.Errorf("step[%d] %q must specify edits or commands", i, step.Name)
+		}
+		names[step.Name] = true
+
+		for j := range step.Edits {
+			edit := &step.Edits[j]
+			if len(edit.File) == 0 || len(edit.Replace) == 0 {
+				return fmt.Errorf("step[%d] %q edit[%d] must specify file and replace", i, step.Name, j)
+			}
+			regex, err := regexp.Compile(edit.Replace)
+			if err != nil {
+				return fmt.Errorf("step[%d] %q edit[%d] has an invalid regex - %w", i, step.Name, j, err)
+			}
+			edit.regex = regex
+		}
+	}
+	return nil
+}
+
+// apply returns the content with the edit applied, it fails if the
+// regular expression does not match anything, so a recipe that has
+// gone stale does not silently do nothing. Applying an edit again is
+// a noop as long as the regex matches the replacement too.
+func (e *Edit) apply(content []byte) ([]byte, error) {
+	if !e.regex.Match(content) {
+		return nil, fmt.Errorf("%q does not match anything in %s", e.Replace, e.File)
+	}
+	return e.regex.ReplaceAll(content, []byte(e.With)), nil
+}
@@ -0,0 +1,113 @@
+package recipe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name   string
+		recipe string
+		err    bool
+	}{
+		{
+			name: "valid",
+			recipe: `
+steps:
+- name: bump-dockerfile-version
+  edits:
+  - file: Dockerfile.rhel
+    replace: 'kubernetes=[0-9.]+'
+    with: 'kubernetes=1.24.0'
+  commit: "UPSTREAM: <drop>: Update hyperkube dockerfile version"
+- name: make-update
+  commands:
+  - make update
+  commit: "UPSTREAM: <drop>: make update"
+`,
+		},
+		{
+			name: "step names must be unique",
+			recipe: `
+steps:
+- name: make-update
+  commands: ["make update"]
+  commit: "UPSTREAM: <drop>: make update"
+- name: make-update
+  commands: ["make update"]
+  commit: "UPSTREAM: <drop>: make update"
+`,
+			err: true,
+		},
+		{
+			name: "step must commit",
+			recipe: `
+steps:
+- name: make-update
+  commands: ["make update"]
+`,
+			err: true,
+		},
+		{
+			name: "invalid regex",
+			recipe: `
+steps:
+- name: bump-dockerfile-version
+  edits:
+  - file: Dockerfile.rhel
+    replace: 'kubernetes=[0-9.+'
+  commit: "UPSTREAM: <drop>: Update hyperkube dockerfile version"
+`,
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), "recipe.yaml")
+			if err := os.WriteFile(fpath, []byte(test.recipe), 0644); err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+
+			_, err := Load(fpath)
+			if test.err != (err != nil) {
+				t.Errorf("Expected error: %t, but got: %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestEditIsIdempotent(t *testing.T) {
+	r := &Recipe{Steps: []Step{{
+		Name:   "bump-dockerfile-version",
+		Commit: "UPSTREAM: <drop>: Update hyperkube dockerfile version",
+		Edits: []Edit{{
+			File:    "Dockerfile.rhel",
+			Replace: `io\.openshift\.build\.versions="kubernetes=[0-9.]+"`,
+			With:    `io.openshift.build.versions="kubernetes=1.24.0"`,
+		}},
+	}}}
+	if err := r.compile(); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	edit := &r.Steps[0].Edits[0]
+
+	content := []byte(`LABEL io.openshift.build.versions="kubernetes=1.23.3"` + "\n")
+	want := `LABEL io.openshift.build.versions="kubernetes=1.24.0"` + "\n"
+	for i := 0; i < 2; i++ {
+		got, err := edit.apply(content)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("Expected: %q, but got: %q", want, got)
+		}
+		content = got
+	}
+
+	if _, err := edit.apply([]byte("FROM builder\n")); err == nil {
+		t.Errorf("Expected an error for an edit that matches nothing")
+	}
+}
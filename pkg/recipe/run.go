@@ -0,0 +1,134 @@
+package recipe
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tkashem/rebase/pkg/backup"
+	"github.com/tkashem/rebase/pkg/command"
+	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
+	"k8s.io/klog/v2"
+)
+
+func New(ctx context.Context, recipe *Recipe, t *target.Target, logDir string, checkpointEvery int) (*cmd, error) {
+	accessor, err := git.Initialize(ctx, t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize git - %w", err)
+	}
+
+	return &cmd{
+		recipe:    recipe,
+		git:       accessor.Git,
+		target:    t.String(),
+		metadata:  t.Metadata("recipe"),
+		stopAtSHA: accessor.StopAtCommitSHA,
+		logDir:    logDir,
+		recorder:  backup.NewRecorder(accessor.Git, "run-recipe", t.String(), checkpointEvery),
+		executor: func(dir string) command.Executor {
+			return command.NewShellExecutor(dir)
+		},
+	}, nil
+}
+
+type cmd struct {
+	recipe                      *Recipe
+	git                         git.Git
+	target, metadata, stopAtSHA string
+	logDir                      string
+	recorder                    *backup.Recorder
+	executor                    func(dir string) command.Executor
+}
+
+func (c *cmd) Run(ctx context.Context) error {
+	klog.InfoS("run-recipe in progress", "target", c.target, "steps", len(c.recipe.Steps), "metadata", c.metadata, "log-dir", c.logDir)
+	if err := os.MkdirAll(c.logDir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory %q - %w", c.logDir, err)
+	}
+
+	done, err := c.done(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.recorder.Backup(ctx); err != nil {
+		return err
+	}
+	for i := range c.recipe.Steps {
+		step := &c.recipe.Steps[i]
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted before step(%d/%d) %s, run the recipe again to resume", i+1, len(c.recipe.Steps), step.Name)
+		}
+		if sha, ok := done[step.Name]; ok {
+			klog.Infof("step(%d/%d) %s status=committed(%s) do=skip", i+1, len(c.recipe.Steps), step.Name, sha)
+			continue
+		}
+
+		logPath := filepath.Join(c.logDir, fmt.Sprintf("%02d-%s.log", i+1, step.Name))
+		klog.Infof("step(%d/%d) %s status=not-committed do=run log=%s", i+1, len(c.recipe.Steps), step.Name, logPath)
+		if err := c.run(ctx, step, logPath); err != nil {
+			return fmt.Errorf("step(%d/%d) %s failed, see %s, fix it and run the recipe again to resume - %w",
+				i+1, len(c.recipe.Steps), step.Name, logPath, err)
+		}
+		if err := c.recorder.Applied(ctx); err != nil {
+			return err
+		}
+	}
+
+	klog.InfoS("run-recipe has completed")
+	return nil
+}
+
+// done returns the steps that are committed in the rebase branch,
+// keyed by step name, a step is committed along with its metadata.
+func (c *cmd) done(ctx context.Context) (map[string]string, error) {
+	commits, err := c.git.Log(ctx, "", c.stopAtSHA)
+	if err != nil {
+		return nil, fmt.Errorf("git log failed with error: %w", err)
+	}
+
+	done := map[string]string{}
+	for _, commit := range commits {
+		if step := git.Metadata(commit.Message, c.metadata); len(step) > 0 {
+			done[step] = commit.Hash.String()[:11]
+		}
+	}
+	return done, nil
+}
+
+func (c *cmd) run(ctx context.Context, step *Step, logPath string) error {
+	log, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %q - %w", logPath, err)
+	}
+	defer log.Close()
+
+	for _, edit := range step.Edits {
+		fpath := filepath.Join(step.Dir, edit.File)
+		content, err := os.ReadFile(fpath)
+		if err != nil {
+			return fmt.Errorf("failed to read %q - %w", fpath, err)
+		}
+		edited, err := edit.apply(content)
+		if err != nil {
+			fmt.Fprintf(log, "edit %s: %v\n", fpath, err)
+			return err
+		}
+		if err := os.WriteFile(fpath, edited, 0644); err != nil {
+			return fmt.Errorf("failed to write %q - %w", fpath, err)
+		}
+		fmt.Fprintf(log, "edit %s: replaced %q with %q\n", fpath, edit.Replace, edit.With)
+	}
+
+	runner := &command.Runner{Executor: c.executor(step.Dir), Output: log}
+	if err := runner.Run(ctx, step.Commands); err != nil {
+		return err
+	}
+
+	return c.git.CommitAll(ctx, []string{
+		step.Commit,
+		fmt.Sprintf("%s=%s", c.metadata, step.Name),
+	})
+}
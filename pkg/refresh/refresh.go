@@ -0,0 +1,220 @@
+package refresh
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// master is the branch the carry commits are merged to
+	master = "openshift/master"
+	// recorded is the key of the openshift commit in the comments of
+	// the carry commit log, the last one recorded wins.
+	recorded = master + "="
+)
+
+func New(fpath, since string) (*cmd, error) {
+	gitAPI, err := git.OpenWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &cmd{
+		git:   gitAPI,
+		fpath: fpath,
+		since: since,
+	}, nil
+}
+
+type cmd struct {
+	git          git.Git
+	fpath, since string
+}
+
+// reported is a commit merged to openshift/master since the carry
+// commit log was generated, that needs a human decision.
+type reported struct {
+	entry  git.LogEntry
+	reason string
+}
+
+func (c *cmd) Run(ctx context.Context) error {
+	content, err := os.ReadFile(c.fpath)
+	if err != nil {
+		return fmt.Errorf("error loading file %q - %w", c.fpath, err)
+	}
+	since := c.since
+	if len(since) == 0 {
+		if since = lastRecorded(string(content)); len(since) == 0 {
+			return fmt.Errorf("no %s commit recorded in %q, specify the commit the log was generated from with --since", master, c.fpath)
+		}
+	}
+
+	head, err := c.git.Commit(ctx, master)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s - %w", master, err)
+	}
+	klog.InfoS("refresh in progress", "carry-commit-file", c.fpath, "since", since, master, head.Hash.String())
+	if strings.HasPrefix(head.Hash.String(), since) {
+		klog.Infof("refresh: %q is up to date with %s", c.fpath, master)
+		return nil
+	}
+
+	reader, err := carry.NewReaderFromFile(ctx, c.fpath, "", nil)
+	if err != nil {
+		return err
+	}
+	existing, err := reader.Read(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries, err := c.git.LogRange(ctx, fmt.Sprintf("%s..%s", since, head.Hash.String()))
+	if err != nil {
+		return err
+	}
+
+	lines, reports, err := c.refresh(ctx, existing, entries)
+	if err != nil {
+		return err
+	}
+
+	b := &strings.Builder{}
+	if !strings.HasSuffix(string(content), "\n") && len(content) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(b, "# refresh: %s%s since=%s added=%d\n", recorded, head.Hash.String(), since, len(lines))
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+
+	file, err := os.OpenFile(c.fpath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %q - %w", c.fpath, err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to append to %q - %w", c.fpath, err)
+	}
+
+	for _, line := range lines {
+		klog.Infof("added: %s", strings.TrimSpace(line))
+	}
+	for _, r := range reports {
+		klog.Infof("needs decision: %s %s - %s", r.entry.SHA[:11], r.entry.Subject, r.reason)
+	}
+	klog.Infof("stats: commits(%d), added(%d), needs-decision(%d)", len(entries), len(lines), len(reports))
+	return nil
+}
+
+// refresh returns the lines to append for the new carry commits, and
+// the commits that revert or rewrite a carry commit already in the log.
+func (c *cmd) refresh(ctx context.Context, existing []*carry.CommitSummary, entries []git.LogEntry) ([]string, []reported, error) {
+	lines := make([]string, 0)
+	reports := make([]reported, 0)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Subject, "UPSTREAM: ") && !strings.HasPrefix(entry.Subject, `Revert "UPSTREAM: `) {
+			continue
+		}
+		if find(existing, func(s *carry.CommitSummary) bool { return strings.HasPrefix(entry.SHA, s.SHA) }) != nil {
+			continue
+		}
+
+		reverted, err := c.reverted(ctx, existing, entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reverted != nil {
+			reports = append(reports, reported{entry: entry, reason: fmt.Sprintf("reverts %s in the log", reverted.String())})
+		}
+
+		// a git revert of a carry does not follow the UPSTREAM convention
+		if !strings.HasPrefix(entry.Subject, "UPSTREAM: ") {
+			if reverted == nil {
+				reports = append(reports, reported{entry: entry, reason: "reverts a carry commit that is not in the log"})
+			}
+			continue
+		}
+
+		if rewritten := find(existing, func(s *carry.CommitSummary) bool { return s.MessageWithPrefix == entry.Subject }); rewritten != nil {
+			reports = append(reports, reported{entry: entry, reason: fmt.Sprintf("rewrites %s in the log", rewritten.String())})
+		}
+
+		line, err := carry.LogLine(entry.SHA[:11], entry.Subject)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to add %s %s - %w", entry.SHA, entry.Subject, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, reports, nil
+}
+
+// reverted returns the commit in the log the given commit reverts, it
+// is matched either by the 'This reverts commit X' line, or by subject.
+func (c *cmd) reverted(ctx context.Context, existing []*carry.CommitSummary, entry git.LogEntry) (*carry.CommitSummary, error) {
+	msg, err := c.git.CommitMessage(ctx, entry.SHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit message of %s - %w", entry.SHA, err)
+	}
+
+	const prefix = "This reverts commit "
+	scanner := bufio.NewScanner(strings.NewReader(msg))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		sha := strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, prefix)), ".")
+		if original := find(existing, func(s *carry.CommitSummary) bool {
+			return strings.HasPrefix(sha, s.SHA) || strings.HasPrefix(s.SHA, sha)
+		}); original != nil {
+			return original, nil
+		}
+	}
+
+	// UPSTREAM: revert: <carry>: foo, or Revert "UPSTREAM: <carry>: foo"
+	subject := strings.TrimPrefix(entry.Subject, "UPSTREAM: revert: ")
+	if subject == entry.Subject {
+		subject = strings.TrimSuffix(strings.TrimPrefix(entry.Subject, `Revert "`), `"`)
+	}
+	if subject == entry.Subject {
+		return nil, nil
+	}
+	return find(existing, func(s *carry.CommitSummary) bool {
+		return s.MessageWithPrefix == subject || s.MessageWithPrefix == "UPSTREAM: "+subject
+	}), nil
+}
+
+func find(existing []*carry.CommitSummary, match func(*carry.CommitSummary) bool) *carry.CommitSummary {
+	for _, s := range existing {
+		if match(s) {
+			return s
+		}
+	}
+	return nil
+}
+
+// lastRecorded returns the last openshift commit recorded in the
+// comments of the carry commit log.
+func lastRecorded(content string) string {
+	var sha string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			if strings.HasPrefix(field, recorded) {
+				sha = strings.TrimPrefix(field, recorded)
+			}
+		}
+	}
+	return sha
+}
@@ -0,0 +1,96 @@
+package refresh
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tkashem/rebase/pkg/carry"
+	"github.com/tkashem/rebase/pkg/git"
+)
+
+// fakeGit implements the subset of git.Git refresh uses, it returns
+// the full commit message of a commit.
+type fakeGit struct {
+	git.Git
+	messages map[string]string
+}
+
+func (f *fakeGit) CommitMessage(_ context.Context, sha string) (string, error) {
+	return f.messages[sha], nil
+}
+
+func TestLastRecorded(t *testing.T) {
+	content := `# generated: openshift/master=7b79481b1f10007ed12f4748c5fc1780f4bc0c75
+	7b79481b1f1			UPSTREAM: <carry>: add c	https://github.com/openshift/kubernetes/commit/7b79481b1f1?w=1
+# refresh: openshift/master=db10c829db8c5cd6ade694c5ad342152c656be76 since=7b79481b1f10007ed12f4748c5fc1780f4bc0c75 added=1
+	a24fd674678			UPSTREAM: 12345: add d	https://github.com/openshift/kubernetes/commit/a24fd674678?w=1	https://github.com/kubernetes/kubernetes/pull/12345
+`
+	if got := lastRecorded(content); got != "db10c829db8c5cd6ade694c5ad342152c656be76" {
+		t.Errorf("Expected the last recorded commit, but got: %q", got)
+	}
+	if got := lastRecorded("\tc6840e84f86\t\t\tUPSTREAM: <carry>: foo\n"); got != "" {
+		t.Errorf("Expected no recorded commit, but got: %q", got)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	existing := make([]*carry.CommitSummary, 0)
+	for _, commit := range [][2]string{{"7b79481b1f1", "UPSTREAM: <carry>: add c"}, {"a24fd674678", "UPSTREAM: <carry>: add d"}} {
+		summary, err := carry.Summarize(commit[0], commit[1])
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		existing = append(existing, summary)
+	}
+
+	const (
+		inLog     = "7b79481b1f10007ed12f4748c5fc1780f4bc0c75"
+		added     = "1111111111111111111111111111111111111111"
+		rewrite   = "2222222222222222222222222222222222222222"
+		byMessage = "3333333333333333333333333333333333333333"
+		bySubject = "4444444444444444444444444444444444444444"
+		unknown   = "5555555555555555555555555555555555555555"
+		upstream  = "6666666666666666666666666666666666666666"
+	)
+	entries := []git.LogEntry{
+		{SHA: inLog, Subject: "UPSTREAM: <carry>: add c"},
+		{SHA: added, Subject: "UPSTREAM: <carry>: add e"},
+		{SHA: rewrite, Subject: "UPSTREAM: <carry>: add d"},
+		{SHA: byMessage, Subject: `Revert "UPSTREAM: <carry>: add c (reworded)"`},
+		{SHA: bySubject, Subject: "UPSTREAM: revert: <carry>: add d"},
+		{SHA: unknown, Subject: `Revert "UPSTREAM: <carry>: add f"`},
+		{SHA: upstream, Subject: "Merge pull request #1 from foo/bar"},
+	}
+	c := &cmd{git: &fakeGit{messages: map[string]string{
+		byMessage: `Revert "UPSTREAM: <carry>: add c (reworded)"` + "\n\nThis reverts commit 7b79481b1f1.\n",
+	}}}
+
+	lines, reports, err := c.refresh(context.TODO(), existing, entries)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	shas := make([]string, 0)
+	for _, line := range lines {
+		shas = append(shas, strings.Fields(line)[0])
+	}
+	if expected := []string{added[:11], rewrite[:11], bySubject[:11]}; !reflect.DeepEqual(expected, shas) {
+		t.Errorf("Expected lines for: %v, but got: %v", expected, shas)
+	}
+
+	got := make([]string, 0)
+	for _, r := range reports {
+		got = append(got, r.entry.SHA[:11]+" "+r.reason)
+	}
+	expected := []string{
+		rewrite[:11] + " rewrites " + existing[1].String() + " in the log",
+		byMessage[:11] + " reverts " + existing[0].String() + " in the log",
+		bySubject[:11] + " reverts " + existing[1].String() + " in the log",
+		unknown[:11] + " reverts a carry commit that is not in the log",
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected reports:\n%s\nbut got:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
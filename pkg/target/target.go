@@ -0,0 +1,169 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"golang.org/x/mod/semver"
+)
+
+// v1.24, v1.24.0, v1.24.0-rc.1, and the older style v1.24.0-rc-0
+var pattern = regexp.MustCompile(`^v(0|[1-9]\d*)\.(0|[1-9]\d*)(?:\.(0|[1-9]\d*)(?:-(alpha|beta|rc)[.-](0|[1-9]\d*))?)?$`)
+
+// Target is an upstream kubernetes release a rebase targets, it is
+// either a minor release line, ie. v1.24, or a tag, ie. v1.24.0-rc.1.
+type Target struct {
+	Major, Minor, Patch int
+
+	// Pre is the pre-release of the tag, ie. rc.1, empty for a GA
+	Pre string
+
+	// as specified by the user, it is used as is in the rebase markers
+	raw   string
+	patch bool
+}
+
+// Parse parses the given target, it rejects anything that is not a
+// minor release line, or a release tag of kubernetes.
+func Parse(s string) (*Target, error) {
+	matches := pattern.FindStringSubmatch(s)
+	if matches == nil {
+		return nil, fmt.Errorf("%q is not a valid target, ie. v1.24, v1.24.0 or v1.24.0-rc.1", s)
+	}
+
+	t := &Target{raw: s}
+	t.Major, _ = strconv.Atoi(matches[1])
+	t.Minor, _ = strconv.Atoi(matches[2])
+	if len(matches[3]) > 0 {
+		t.patch = true
+		t.Patch, _ = strconv.Atoi(matches[3])
+	}
+	if len(matches[4]) > 0 {
+		t.Pre = matches[4] + "." + matches[5]
+	}
+	return t, nil
+}
+
+// String returns the target as it was specified.
+func (t *Target) String() string { return t.raw }
+
+// Marker returns the pattern of the rebase marker commit of the target,
+// ie. openshift-rebase(v1.24):marker
+func (t *Target) Marker() string { return t.Metadata("marker") }
+
+// Metadata returns the given rebase metadata key of the target, as it
+// is recorded in a commit message, ie. openshift-rebase(v1.24):source
+func (t *Target) Metadata(key string) string {
+	return fmt.Sprintf("openshift-rebase(%s):%s", t.raw, key)
+}
+
+// IsLine returns true if the target is a minor release line, ie. v1.24
+func (t *Target) IsLine() bool { return !t.patch }
+
+// Release returns the major.minor.patch of the target, the pre-release
+// is dropped, ie. v1.24.0-rc-0 => 1.24.0
+func (t *Target) Release() string {
+	return fmt.Sprintf("%d.%d.%d", t.Major, t.Minor, t.Patch)
+}
+
+// semver returns the target in the canonical semver form, a minor
+// release line has no patch.
+func (t *Target) semver() string {
+	v := fmt.Sprintf("v%d.%d", t.Major, t.Minor)
+	if t.patch {
+		v = fmt.Sprintf("%s.%d", v, t.Patch)
+	}
+	if len(t.Pre) > 0 {
+		v = v + "-" + t.Pre
+	}
+	return v
+}
+
+// Includes returns true if the given tag belongs to the target, a
+// minor release line includes every tag of the minor release, a GA
+// release includes its pre-releases, and a pre-release includes
+// itself only.
+func (t *Target) Includes(tag *Target) bool {
+	if !tag.patch || tag.Major != t.Major || tag.Minor != t.Minor {
+		return false
+	}
+	switch {
+	case !t.patch:
+		return true
+	case tag.Patch != t.Patch:
+		return false
+	case len(t.Pre) == 0:
+		return true
+	}
+	return tag.Pre == t.Pre
+}
+
+// Latest returns the latest tag, GA or pre-release, among the given
+// tags that the target includes.
+func Latest(t *Target, tags []string) (string, error) {
+	matched := parseTags(tags, t.Includes)
+	if len(matched) == 0 {
+		return "", fmt.Errorf("no upstream tag found for target %s", t.String())
+	}
+	return matched[len(matched)-1].raw, nil
+}
+
+// Base returns the GA tag of the previous minor release, the carry
+// commits of the rebase are the ones added since the base, ie. the
+// base of v1.24.0-rc.1 is v1.23.0
+func Base(t *Target, tags []string) (string, error) {
+	if t.Minor == 0 {
+		return "", fmt.Errorf("target %s has no previous minor release", t.String())
+	}
+	base := fmt.Sprintf("v%d.%d.0", t.Major, t.Minor-1)
+	for _, tag := range tags {
+		if tag == base {
+			return base, nil
+		}
+	}
+	return "", fmt.Errorf("base %s of target %s not found in the upstream tags", base, t.String())
+}
+
+// parseTags returns the tags accepted by the filter, in semver order,
+// a tag that does not parse is ignored.
+func parseTags(tags []string, filter func(*Target) bool) []*Target {
+	parsed := make([]*Target, 0)
+	for _, tag := range tags {
+		t, err := Parse(tag)
+		if err != nil || !filter(t) {
+			continue
+		}
+		parsed = append(parsed, t)
+	}
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return semver.Compare(parsed[i].semver(), parsed[j].semver()) < 0
+	})
+	return parsed
+}
+
+// Upstream is the remote the kubernetes tags are listed from.
+const Upstream = "upstream"
+
+// TagLister lists the tags of a git remote.
+type TagLister interface {
+	RemoteTags(ctx context.Context, remote string) ([]string, error)
+}
+
+// Resolve returns the latest upstream tag the target includes, and
+// the base of the target.
+func Resolve(ctx context.Context, t *Target, lister TagLister) (tag, base string, err error) {
+	tags, err := lister.RemoteTags(ctx, Upstream)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list the tags of %s - %w", Upstream, err)
+	}
+	if tag, err = Latest(t, tags); err != nil {
+		return "", "", err
+	}
+	if base, err = Base(t, tags); err != nil {
+		return "", "", err
+	}
+	return tag, base, nil
+}
@@ -0,0 +1,97 @@
+package target
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		target  string
+		release string
+		line    bool
+		err     bool
+	}{
+		{target: "v1.24", release: "1.24.0", line: true},
+		{target: "v1.24.0", release: "1.24.0"},
+		{target: "v1.24.0-rc.1", release: "1.24.0"},
+		{target: "v1.24.0-rc-0", release: "1.24.0"},
+		{target: "v1.24.3", release: "1.24.3"},
+		{target: "1.24", err: true},
+		{target: "v1", err: true},
+		{target: "v1.24.0-foo", err: true},
+		{target: "v.1.24", err: true},
+		{target: "master", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			got, err := Parse(test.target)
+			if test.err != (err != nil) {
+				t.Fatalf("Expected error: %t, but got: %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if got.Release() != test.release {
+				t.Errorf("Expected release: %q, but got: %q", test.release, got.Release())
+			}
+			if got.IsLine() != test.line {
+				t.Errorf("Expected line: %t, but got: %t", test.line, got.IsLine())
+			}
+			if got.String() != test.target {
+				t.Errorf("Expected the target as is: %q, but got: %q", test.target, got.String())
+			}
+			if marker := "openshift-rebase(" + test.target + "):marker"; got.Marker() != marker {
+				t.Errorf("Expected marker: %q, but got: %q", marker, got.Marker())
+			}
+		})
+	}
+}
+
+func TestLatestAndBase(t *testing.T) {
+	tags := []string{
+		"v1.23.0-rc.0", "v1.23.0", "v1.23.5",
+		"v1.24.0-alpha.4", "v1.24.0-beta.0", "v1.24.0-rc.0", "v1.24.0-rc.1", "v1.24.0", "v1.24.1",
+		"v1.25.0-alpha.0", "kubernetes-1.24.0",
+	}
+
+	tests := []struct {
+		target string
+		latest string
+		base   string
+	}{
+		{target: "v1.24", latest: "v1.24.1", base: "v1.23.0"},
+		{target: "v1.24.0", latest: "v1.24.0", base: "v1.23.0"},
+		{target: "v1.24.0-rc.1", latest: "v1.24.0-rc.1", base: "v1.23.0"},
+		{target: "v1.25", latest: "v1.25.0-alpha.0", base: "v1.24.0"},
+		{target: "v1.23", latest: "v1.23.5"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			target, err := Parse(test.target)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+
+			latest, err := Latest(target, tags)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if latest != test.latest {
+				t.Errorf("Expected latest: %q, but got: %q", test.latest, latest)
+			}
+
+			base, err := Base(target, tags)
+			if len(test.base) == 0 {
+				if err == nil {
+					t.Errorf("Expected an error, but got base: %q", base)
+				}
+				return
+			}
+			if base != test.base {
+				t.Errorf("Expected base: %q, but got: %q - %v", test.base, base, err)
+			}
+		})
+	}
+}
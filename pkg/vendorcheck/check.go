@@ -0,0 +1,110 @@
+package vendorcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+func New(root string) (*cmd, error) {
+	return &cmd{root: root}, nil
+}
+
+type cmd struct {
+	root string
+}
+
+func (c *cmd) Run(_ context.Context) error {
+	dirs, err := modules(c.root)
+	if err != nil {
+		return err
+	}
+	klog.InfoS("check-vendor in progress", "root", c.root, "modules", len(dirs))
+
+	mismatches := make([]Mismatch, 0)
+	vendoredModules := 0
+	for _, dir := range dirs {
+		gomod, err := os.ReadFile(filepath.Join(c.root, dir, "go.mod"))
+		if err != nil {
+			return fmt.Errorf("failed to read %s/go.mod - %w", dir, err)
+		}
+		modulesTxt, err := os.ReadFile(filepath.Join(c.root, dir, "vendor", "modules.txt"))
+		if errors.Is(err, fs.ErrNotExist) {
+			klog.V(2).Infof("check-vendor: %s has no vendor directory", dir)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s/vendor/modules.txt - %w", dir, err)
+		}
+		vendoredModules++
+
+		found, err := check(dir, gomod, modulesTxt, c.fix(dir))
+		if err != nil {
+			return err
+		}
+		mismatches = append(mismatches, found...)
+	}
+
+	b := &strings.Builder{}
+	for _, m := range mismatches {
+		fmt.Fprintf(b, "%s\n\tfix: %s\n", m.String(), m.Fix)
+	}
+	if len(mismatches) > 0 {
+		klog.Infof("inconsistent vendoring:\n%s", b.String())
+	}
+	klog.Infof("stats: modules(%d), vendored(%d), mismatches(%d)", len(dirs), vendoredModules, len(mismatches))
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("found %d inconsistencies between go.mod and vendor/modules.txt", len(mismatches))
+	}
+	return nil
+}
+
+// fix returns the command that syncs the vendor directory of the
+// module in dir, the root module of kubernetes has its own script.
+func (c *cmd) fix(dir string) string {
+	if dir == "." {
+		if _, err := os.Stat(filepath.Join(c.root, "hack", "update-vendor.sh")); err == nil {
+			return "hack/update-vendor.sh"
+		}
+		return "go mod vendor"
+	}
+	return fmt.Sprintf("(cd %s && go mod vendor)", dir)
+}
+
+// modules returns the directory, relative to root, of every go.mod
+// file in the tree, the vendor and the build output are skipped.
+func modules(root string) ([]string, error) {
+	dirs := make([]string, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case "vendor", "_output", ".git", "testdata":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+		dir, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, dir)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find go.mod files in %q - %w", root, err)
+	}
+	return dirs, nil
+}
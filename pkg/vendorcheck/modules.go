@@ -0,0 +1,171 @@
+package vendorcheck
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Class is a kind of inconsistency between go.mod and vendor/modules.txt,
+// the go command reports each of them as 'go: inconsistent vendoring'.
+type Class string
+
+const (
+	NotMarkedExplicit   Class = "is explicitly required in go.mod, but not marked as explicit in vendor/modules.txt"
+	VersionMismatch     Class = "is explicitly required in go.mod, but vendor/modules.txt has a different version"
+	NotRequired         Class = "is marked as explicit in vendor/modules.txt, but not explicitly required in go.mod"
+	NotMarkedReplaced   Class = "is replaced in go.mod, but not marked as replaced in vendor/modules.txt"
+	ReplacementMismatch Class = "is replaced in go.mod, but vendor/modules.txt has a different replacement"
+	NotReplaced         Class = "is marked as replaced in vendor/modules.txt, but not replaced in go.mod"
+)
+
+// Mismatch is an inconsistency of a module in the vendor directory of
+// the go.mod file in Dir.
+type Mismatch struct {
+	Dir    string
+	Module string
+	Class  Class
+	Detail string
+	Fix    string
+}
+
+func (m Mismatch) String() string {
+	if len(m.Detail) > 0 {
+		return fmt.Sprintf("%s: %s %s (%s)", m.Dir, m.Module, m.Class, m.Detail)
+	}
+	return fmt.Sprintf("%s: %s %s", m.Dir, m.Module, m.Class)
+}
+
+// vendored is a module as it is recorded in vendor/modules.txt
+type vendored struct {
+	version  string
+	explicit bool
+	replace  *module.Version
+}
+
+// readModulesTxt parses vendor/modules.txt, keyed by module path.
+//
+//	# path version [=> new-path [new-version]]
+//	# path => new-path [new-version]
+//	## explicit[; go 1.17]
+func readModulesTxt(data []byte) (map[string]*vendored, error) {
+	modules := map[string]*vendored{}
+	var current *vendored
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "## "):
+			if current == nil {
+				return nil, fmt.Errorf("unexpected annotation %q", line)
+			}
+			for _, annotation := range strings.Split(strings.TrimPrefix(line, "## "), ";") {
+				if strings.TrimSpace(annotation) == "explicit" {
+					current.explicit = true
+				}
+			}
+		case strings.HasPrefix(line, "# "):
+			split := strings.SplitN(strings.TrimPrefix(line, "# "), "=>", 2)
+			old := strings.Fields(split[0])
+			if len(old) == 0 || len(old) > 2 {
+				return nil, fmt.Errorf("invalid module line %q", line)
+			}
+			current = &vendored{}
+			if len(old) == 2 {
+				current.version = old[1]
+			}
+			if len(split) == 2 {
+				replace := strings.Fields(split[1])
+				if len(replace) == 0 || len(replace) > 2 {
+					return nil, fmt.Errorf("invalid replacement in %q", line)
+				}
+				current.replace = &module.Version{Path: replace[0]}
+				if len(replace) == 2 {
+					current.replace.Version = replace[1]
+				}
+			}
+			modules[old[0]] = current
+		}
+	}
+
+	return modules, scanner.Err()
+}
+
+// check compares a go.mod file with its vendor/modules.txt, the same
+// way the go command does. The mismatches found from go.mod come first,
+// followed by the ones found from vendor/modules.txt.
+func check(dir string, gomod, modulesTxt []byte, fix string) ([]Mismatch, error) {
+	f, err := modfile.Parse("go.mod", gomod, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s/go.mod - %w", dir, err)
+	}
+	modules, err := readModulesTxt(modulesTxt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s/vendor/modules.txt - %w", dir, err)
+	}
+
+	mismatches := make([]Mismatch, 0)
+	add := func(path string, class Class, detail, fix string) {
+		mismatches = append(mismatches, Mismatch{Dir: dir, Module: path, Class: class, Detail: detail, Fix: fix})
+	}
+
+	required := map[string]bool{}
+	for _, require := range f.Require {
+		required[require.Mod.Path] = true
+		v, ok := modules[require.Mod.Path]
+		switch {
+		case !ok || !v.explicit:
+			add(require.Mod.Path, NotMarkedExplicit, "", fix)
+		case v.version != require.Mod.Version:
+			add(require.Mod.Path, VersionMismatch, fmt.Sprintf("go.mod: %s, vendor/modules.txt: %s", require.Mod.Version, v.version), fix)
+		}
+	}
+
+	replaced := map[string]bool{}
+	for _, replace := range f.Replace {
+		v, ok := modules[replace.Old.Path]
+		if len(replace.Old.Version) > 0 && (!ok || v.version != replace.Old.Version) {
+			// a replacement of a version that is not vendored is
+			// not recorded, it does not apply
+			continue
+		}
+		replaced[replace.Old.Path] = true
+		if !ok {
+			add(replace.Old.Path, NotMarkedReplaced, "", fix)
+			continue
+		}
+		switch {
+		case v.replace == nil:
+			add(replace.Old.Path, NotMarkedReplaced, "", fix)
+		case *v.replace != replace.New:
+			add(replace.Old.Path, ReplacementMismatch, fmt.Sprintf("go.mod: %s, vendor/modules.txt: %s", replace.New.String(), v.replace.String()), fix)
+		}
+	}
+
+	paths := make([]string, 0, len(modules))
+	for path := range modules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		v := modules[path]
+		if v.explicit && !required[path] {
+			add(path, NotRequired, "", fix)
+		}
+		if v.replace != nil && !replaced[path] {
+			replace := fmt.Sprintf("(cd %s && go mod edit -replace %s=%s)", dir, path, v.replace.Path)
+			if len(v.replace.Version) > 0 {
+				replace = fmt.Sprintf("(cd %s && go mod edit -replace %s=%s@%s)", dir, path, v.replace.Path, v.replace.Version)
+			}
+			add(path, NotReplaced, fmt.Sprintf("vendor/modules.txt: => %s", v.replace.String()), fmt.Sprintf("%s, or %s", replace, fix))
+		}
+	}
+
+	return mismatches, nil
+}
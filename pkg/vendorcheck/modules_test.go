@@ -0,0 +1,73 @@
+package vendorcheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	gomod := `module k8s.io/code-generator
+
+go 1.18
+
+require (
+	github.com/onsi/ginkgo v4.7.0-origin.0+incompatible
+	github.com/onsi/gomega v1.10.1
+	github.com/google/uuid v1.1.2
+	k8s.io/klog/v2 v2.60.1
+	k8s.io/gengo v0.0.0-20211129171323-c02415ce4185
+)
+
+replace (
+	github.com/onsi/ginkgo => github.com/openshift/ginkgo v4.7.0-origin.0+incompatible
+	github.com/google/uuid v1.0.0 => github.com/google/uuid v1.1.1
+)
+`
+	modulesTxt := `# github.com/google/uuid v1.1.2
+## explicit
+github.com/google/uuid
+# github.com/onsi/ginkgo v4.7.0-origin.0+incompatible => github.com/openshift/ginkgo v4.7.0-origin.0+incompatible
+github.com/onsi/ginkgo
+# github.com/onsi/gomega v1.10.1
+github.com/onsi/gomega
+# k8s.io/code-generator => ../code-generator
+# k8s.io/gengo v0.0.0-20211129171323-c02415ce4185
+## explicit; go 1.13
+k8s.io/gengo/args
+# k8s.io/klog/v2 v2.40.1
+## explicit; go 1.13
+k8s.io/klog/v2
+# sigs.k8s.io/yaml v1.2.0
+## explicit; go 1.12
+sigs.k8s.io/yaml
+`
+
+	got, err := check("staging/src/k8s.io/code-generator", []byte(gomod), []byte(modulesTxt), "go mod vendor")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	type pair struct {
+		module string
+		class  Class
+	}
+	want := []pair{
+		{module: "github.com/onsi/ginkgo", class: NotMarkedExplicit},
+		{module: "github.com/onsi/gomega", class: NotMarkedExplicit},
+		{module: "k8s.io/klog/v2", class: VersionMismatch},
+		{module: "k8s.io/code-generator", class: NotReplaced},
+		{module: "sigs.k8s.io/yaml", class: NotRequired},
+	}
+	pairs := make([]pair, 0)
+	for _, m := range got {
+		pairs = append(pairs, pair{module: m.Module, class: m.Class})
+	}
+	if !reflect.DeepEqual(want, pairs) {
+		t.Fatalf("Expected mismatches: %v, but got: %v", want, pairs)
+	}
+
+	fix := "(cd staging/src/k8s.io/code-generator && go mod edit -replace k8s.io/code-generator=../code-generator), or go mod vendor"
+	if got[3].Fix != fix {
+		t.Errorf("Expected fix: %q, but got: %q", fix, got[3].Fix)
+	}
+}
@@ -1,7 +1,7 @@
 package verify
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -11,10 +11,11 @@ import (
 	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/tkashem/rebase/pkg/carry"
 	"github.com/tkashem/rebase/pkg/git"
+	"github.com/tkashem/rebase/pkg/target"
 	"k8s.io/klog/v2"
 )
 
-func New(reader carry.CommitReader, target string) (*cmd, error) {
+func New(reader carry.CommitReader, t *target.Target) (*cmd, error) {
 	workingDir, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get working directory: %w", err)
@@ -26,14 +27,14 @@ func New(reader carry.CommitReader, target string) (*cmd, error) {
 		return nil, fmt.Errorf("failed to open gitAPI workspace at %q - %w", workingDir, err)
 	}
 	klog.InfoS("opened gitAPI repository successfully", "working-directory", workingDir)
-	klog.InfoS("rebase target", "version", target)
+	klog.InfoS("rebase target", "version", t.String())
 
 	return &cmd{
 		reader:   reader,
 		git:      gitAPI,
-		target:   target,
-		marker:   fmt.Sprintf("openshift-rebase(%s):marker", target),
-		metadata: fmt.Sprintf("openshift-rebase(%s):source", target),
+		target:   t.String(),
+		marker:   t.Marker(),
+		metadata: t.Metadata("source"),
 	}, nil
 }
 
@@ -43,14 +44,14 @@ type cmd struct {
 	target, marker, metadata string
 }
 
-func (c *cmd) Run() error {
-	if err := c.git.CheckRemotes(); err != nil {
+func (c *cmd) Run(ctx context.Context) error {
+	if err := c.git.CheckRemotes(ctx); err != nil {
 		return fmt.Errorf("git repo not setup properly: %v", err)
 	}
 
 	klog.InfoS("rebase marker", "target", c.target, "pattern", c.marker, "metadata", c.metadata)
 
-	markerCommit, err := c.git.FindRebaseMarkerCommit("", c.marker)
+	markerCommit, err := c.git.FindRebaseMarkerCommit(ctx, "", c.marker)
 	if err != nil {
 		return err
 	}
@@ -58,13 +59,18 @@ func (c *cmd) Run() error {
 		"rebase-marker-sha", markerCommit.Hash.String(), "message", markerCommit.Message)
 
 	// this is our source, carry commits we want to pick in new rebase target
-	carries, err := c.reader.Read()
+	carries, err := c.reader.Read(ctx)
 	if err != nil {
 		return err
 	}
+	for _, carry := range carries {
+		if carry.Override != nil {
+			klog.Infof("override matched: %s - %s", carry.Override.String(), carry.String())
+		}
+	}
 
 	// this is the list of commits picked in this branch
-	picked, err := c.git.Log("", markerCommit.Hash.String())
+	picked, err := c.git.Log(ctx, "", markerCommit.Hash.String())
 	if err != nil {
 		return err
 	}
@@ -77,9 +83,10 @@ func (c *cmd) Run() error {
 	//overrides := &overrides{git: c.git, carries: carries}
 	//actual := &actual{git: c.git, carries: picked}
 
+	carry.PrintReverts(c.reader)
 	newCarries, drops := sanitize(carries)
 	klog.Infof("stats: total(%d), carries(%d), drops(%d), picked(%d)", len(carries), len(newCarries), len(drops), len(picked))
-	klog.Infof("diff: \n%s", cmp.Diff(c.expected(newCarries), c.got(picked)))
+	klog.Infof("diff: \n%s", cmp.Diff(c.expected(newCarries), c.got(ctx, picked)))
 	return nil
 }
 
@@ -98,11 +105,11 @@ func sanitize(all []*carry.CommitSummary) ([]*carry.CommitSummary, []*carry.Comm
 	return carries, drops
 }
 
-func (c *cmd) got(picked []*gitv5object.Commit) []descriptor {
+func (c *cmd) got(ctx context.Context, picked []*gitv5object.Commit) []descriptor {
 	ex := make([]descriptor, 0)
 	for i := len(picked) - 1; i >= 0; i-- {
 		split := strings.SplitN(picked[i].Message, "\n", 2)
-		ex = append(ex, descriptor{Commit: c.getSourceCommitHash(picked[i].Message), Message: split[0]})
+		ex = append(ex, descriptor{Commit: c.resolve(ctx, git.Metadata(picked[i].Message, c.metadata)), Message: split[0]})
 	}
 	return ex
 }
@@ -115,19 +122,18 @@ func (c *cmd) expected(carries []*carry.CommitSummary) []descriptor {
 	return ex
 }
 
-func (c *cmd) getSourceCommitHash(msg string) string {
-	reader := strings.NewReader(msg)
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		if strings.Contains(scanner.Text(), c.metadata) {
-			split := strings.Split(scanner.Text(), "=")
-			if len(split) >= 2 {
-				return split[1]
-			}
-		}
+// resolve expands the source SHA from the rebase metadata, a branch
+// picked by an older version of apply records abbreviated SHAs.
+func (c *cmd) resolve(ctx context.Context, sha string) string {
+	if len(sha) == 0 {
+		return sha
 	}
-
-	return ""
+	full, err := c.git.ResolveSHA(ctx, sha)
+	if err != nil {
+		klog.ErrorS(err, "failed to resolve source commit in rebase metadata", "sha", sha)
+		return sha
+	}
+	return full
 }
 
 type descriptor struct {
@@ -0,0 +1,300 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/git"
+	"k8s.io/klog/v2"
+)
+
+// Mode tells a command how to treat a pick that it started in a
+// previous run, and that stopped on a conflict.
+type Mode string
+
+const (
+	// Start refuses to run while a pick is in progress
+	Start Mode = ""
+	// Continue completes the pick after the conflict is resolved
+	Continue Mode = "continue"
+	// Abort rolls back the pick, and stops
+	Abort Mode = "abort"
+)
+
+// Pick is the record of a cherry-pick a command has started, it is
+// removed once the pick is complete.
+type Pick struct {
+	Command string `json:"command"`
+	Target  string `json:"target"`
+	// Carry is the SHA of the carry commit being applied
+	Carry string `json:"carry"`
+	// Picking is the SHA of the commit being cherry-picked, it is the
+	// carry, or the resolved copy of the carry in another branch.
+	Picking string `json:"picking"`
+	// Head is the SHA of HEAD before the pick started
+	Head string `json:"head"`
+}
+
+// Guard keeps a command from picking into a working tree that is not
+// in a state it can safely pick into.
+type Guard struct {
+	git             git.Git
+	command, target string
+	dir             string
+	lock            *lock
+}
+
+func New(ctx context.Context, gitAPI git.Git, command, target string) (*Guard, error) {
+	ws, err := gitAPI.Workspace(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect the working tree - %w", err)
+	}
+	return &Guard{
+		git:     gitAPI,
+		command: command,
+		target:  target,
+		dir:     filepath.Join(ws.GitDir, "openshift-rebase"),
+	}, nil
+}
+
+// Lock makes sure no other rebase process runs in the working tree.
+func (g *Guard) Lock() error {
+	if err := os.MkdirAll(g.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %q - %w", g.dir, err)
+	}
+	l, err := acquire(filepath.Join(g.dir, "lock"), g.command)
+	if err != nil {
+		return err
+	}
+	g.lock = l
+	return nil
+}
+
+func (g *Guard) Unlock() {
+	if g.lock != nil {
+		g.lock.release()
+		g.lock = nil
+	}
+}
+
+// Preflight inspects the working tree before the command makes any
+// change to it. With Continue, the pick that stopped on a conflict is
+// completed, and it is returned so the command can finish applying it.
+func (g *Guard) Preflight(ctx context.Context, mode Mode) (*Pick, error) {
+	ws, pick, head, err := g.inspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st, err := g.classify(ws, pick, head)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case st == clean && mode == Continue && pick == nil:
+		return nil, fmt.Errorf("no pick started by %s is in progress, there is nothing to continue", g.command)
+	case st == clean:
+		if pick != nil {
+			// the pick was aborted by hand, it will be picked again
+			klog.Infof("the pick of %s was rolled back, it will be picked again", pick.Carry)
+			if err := g.Finish(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case mode != Continue:
+		return nil, fmt.Errorf("the pick of %s by %s stopped on a conflict, resolve the conflict and run %s with --continue, or run it with --abort",
+			pick.Carry, g.command, g.command)
+	case st == conflicted:
+		if len(ws.Unmerged) > 0 {
+			return nil, fmt.Errorf("the pick of %s has unresolved conflicts, resolve and stage them first: %s",
+				pick.Carry, strings.Join(ws.Unmerged, ", "))
+		}
+		if err := g.git.ContinueCherryPick(ctx); err != nil {
+			return nil, fmt.Errorf("failed to continue the pick of %s - %w", pick.Carry, err)
+		}
+	}
+
+	klog.Infof("status=conflict-resolved do=continue - %s", pick.Carry)
+	return pick, nil
+}
+
+// Abort rolls back the pick that stopped on a conflict, a commit of the
+// resolution is dropped as well.
+func (g *Guard) Abort(ctx context.Context) error {
+	ws, pick, head, err := g.inspect(ctx)
+	if err != nil {
+		return err
+	}
+	if pick == nil {
+		return fmt.Errorf("no pick started by %s is in progress, there is nothing to abort", g.command)
+	}
+	st, err := g.classify(ws, pick, head)
+	if err != nil {
+		return err
+	}
+
+	switch st {
+	case conflicted:
+		if err := g.git.AbortCherryPick(ctx); err != nil {
+			return err
+		}
+	case committed:
+		klog.Infof("dropping the resolution of %s committed as %s", pick.Carry, head.Hash.String())
+		if err := g.git.ResetHard(ctx, pick.Head); err != nil {
+			return err
+		}
+	}
+	if err := g.Finish(); err != nil {
+		return err
+	}
+	klog.InfoS("aborted the pick", "carry", pick.Carry, "head", pick.Head)
+	return nil
+}
+
+// Reset moves the current branch to the given commit. A pick that
+// stopped on a conflict is discarded along with its record, whichever
+// command started it, any other operation in progress, or uncommitted
+// change, is left for the user to handle.
+func (g *Guard) Reset(ctx context.Context, sha string) error {
+	ws, pick, _, err := g.inspect(ctx)
+	if err != nil {
+		return err
+	}
+	if ws.IndexLocked {
+		return fmt.Errorf("%s exists, another git process is running in this working tree",
+			filepath.Join(ws.GitDir, "index.lock"))
+	}
+
+	picking := pick != nil && len(ws.Operations) == 1 && ws.Operations[0] == git.CherryPickInProgress &&
+		strings.HasPrefix(ws.CherryPickHead, pick.Picking)
+	switch {
+	case picking:
+		klog.Infof("discarding the pick of %s by %s that stopped on a conflict", pick.Carry, pick.Command)
+		if err := g.git.AbortCherryPick(ctx); err != nil {
+			return err
+		}
+	case len(ws.Operations) > 0:
+		return fmt.Errorf("a git %s is in progress, finish it or abort it first", ws.Operations[0])
+	case len(ws.Changed) > 0:
+		return fmt.Errorf("the working tree has uncommitted changes, commit or stash them first: %s",
+			strings.Join(ws.Changed, ", "))
+	}
+
+	if err := g.git.ResetHard(ctx, sha); err != nil {
+		return err
+	}
+	return g.Finish()
+}
+
+// Started records that the given commit is being picked for the carry.
+func (g *Guard) Started(ctx context.Context, carry, picking string) error {
+	head, err := g.git.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	data, err := json.Marshal(&Pick{
+		Command: g.command,
+		Target:  g.target,
+		Carry:   carry,
+		Picking: picking,
+		Head:    head.Hash.String(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(g.pickPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to record the pick of %s - %w", carry, err)
+	}
+	return nil
+}
+
+// Finish removes the record of the pick in progress.
+func (g *Guard) Finish() error {
+	if err := os.Remove(g.pickPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %q - %w", g.pickPath(), err)
+	}
+	return nil
+}
+
+func (g *Guard) pickPath() string { return filepath.Join(g.dir, "pick") }
+
+func (g *Guard) inspect(ctx context.Context) (*git.Workspace, *Pick, *gitv5object.Commit, error) {
+	ws, err := g.git.Workspace(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to inspect the working tree - %w", err)
+	}
+	head, err := g.git.Head(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	data, err := os.ReadFile(g.pickPath())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return ws, nil, head, nil
+	case err != nil:
+		return nil, nil, nil, fmt.Errorf("failed to read %q - %w", g.pickPath(), err)
+	}
+	pick := &Pick{}
+	if err := json.Unmarshal(data, pick); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse %q - %w", g.pickPath(), err)
+	}
+	return ws, pick, head, nil
+}
+
+// state is what the preflight found in the working tree.
+type state int
+
+const (
+	// no pick is in progress
+	clean state = iota
+	// a pick we started stopped on a conflict, it is still in progress
+	conflicted
+	// a pick we started stopped on a conflict, and the resolution has
+	// been committed by hand
+	committed
+)
+
+// classify tells a pick this command started apart from an operation
+// someone else started, only the former can be continued or aborted.
+func (g *Guard) classify(ws *git.Workspace, pick *Pick, head *gitv5object.Commit) (state, error) {
+	if ws.IndexLocked {
+		return clean, fmt.Errorf("%s exists, another git process is running in this working tree",
+			filepath.Join(ws.GitDir, "index.lock"))
+	}
+	if pick != nil && (pick.Command != g.command || pick.Target != g.target) {
+		return clean, fmt.Errorf("a pick of %s started by %s --target=%s is in progress, finish it with that command first",
+			pick.Carry, pick.Command, pick.Target)
+	}
+
+	for _, operation := range ws.Operations {
+		if operation == git.CherryPickInProgress && pick != nil && strings.HasPrefix(ws.CherryPickHead, pick.Picking) {
+			return conflicted, nil
+		}
+		if operation == git.CherryPickInProgress {
+			return clean, fmt.Errorf("a cherry-pick of %s not started by %s is in progress, finish it or abort it first",
+				ws.CherryPickHead, g.command)
+		}
+		return clean, fmt.Errorf("a git %s not started by %s is in progress, finish it or abort it first", operation, g.command)
+	}
+	if len(ws.Changed) > 0 {
+		return clean, fmt.Errorf("the working tree has uncommitted changes, commit or stash them first: %s",
+			strings.Join(ws.Changed, ", "))
+	}
+
+	if pick == nil || head.Hash.String() == pick.Head {
+		return clean, nil
+	}
+	if head.NumParents() > 0 && head.ParentHashes[0].String() == pick.Head {
+		return committed, nil
+	}
+	return clean, fmt.Errorf("HEAD has moved past the pick of %s since it started at %s, reset the branch or remove %q",
+		pick.Carry, pick.Head, g.pickPath())
+}
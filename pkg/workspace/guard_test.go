@@ -0,0 +1,74 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	gitv5object "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tkashem/rebase/pkg/git"
+)
+
+func TestClassify(t *testing.T) {
+	const (
+		before = "1111111111111111111111111111111111111111"
+		after  = "2222222222222222222222222222222222222222"
+		carry  = "c6840e84f86"
+	)
+	pick := &Pick{Command: "apply", Target: "v1.24", Carry: carry, Picking: carry, Head: before}
+	head := &gitv5object.Commit{Hash: plumbing.NewHash(before)}
+	resolved := &gitv5object.Commit{Hash: plumbing.NewHash(after), ParentHashes: []plumbing.Hash{plumbing.NewHash(before)}}
+	unrelated := &gitv5object.Commit{Hash: plumbing.NewHash(after), ParentHashes: []plumbing.Hash{plumbing.NewHash(after)}}
+
+	tests := []struct {
+		name     string
+		ws       *git.Workspace
+		pick     *Pick
+		head     *gitv5object.Commit
+		expected state
+		err      bool
+	}{
+		{name: "clean", ws: &git.Workspace{}, head: head, expected: clean},
+		{name: "dirty", ws: &git.Workspace{Changed: []string{"pkg/a.go"}}, head: head, err: true},
+		{name: "index locked", ws: &git.Workspace{IndexLocked: true}, head: head, err: true},
+		{
+			name: "our cherry-pick stopped on a conflict",
+			ws: &git.Workspace{
+				Operations:     []git.Operation{git.CherryPickInProgress},
+				CherryPickHead: carry + "0123456789abcdef0123456789",
+				Changed:        []string{"pkg/a.go"},
+			},
+			pick: pick, head: head, expected: conflicted,
+		},
+		{
+			name: "foreign cherry-pick",
+			ws: &git.Workspace{
+				Operations:     []git.Operation{git.CherryPickInProgress},
+				CherryPickHead: "a24fd6746780123456789abcdef0123456789abc",
+			},
+			pick: pick, head: head, err: true,
+		},
+		{name: "foreign rebase", ws: &git.Workspace{Operations: []git.Operation{git.RebaseInProgress}}, head: head, err: true},
+		{name: "resolution committed by hand", ws: &git.Workspace{}, pick: pick, head: resolved, expected: committed},
+		{name: "pick aborted by hand", ws: &git.Workspace{}, pick: pick, head: head, expected: clean},
+		{name: "HEAD moved past the pick", ws: &git.Workspace{}, pick: pick, head: unrelated, err: true},
+		{
+			name: "pick started by another command",
+			ws:   &git.Workspace{},
+			pick: &Pick{Command: "copy", Target: "v1.24", Carry: carry, Picking: carry, Head: before},
+			head: head, err: true,
+		},
+	}
+
+	g := &Guard{command: "apply", target: "v1.24"}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := g.classify(test.ws, test.pick, test.head)
+			if test.err != (err != nil) {
+				t.Fatalf("Expected error: %t, but got: %v", test.err, err)
+			}
+			if err == nil && got != test.expected {
+				t.Errorf("Expected state: %d, but got: %d", test.expected, got)
+			}
+		})
+	}
+}
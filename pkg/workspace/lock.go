@@ -0,0 +1,81 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"k8s.io/klog/v2"
+)
+
+// lock is held by a rebase command for the duration of its run, so two
+// commands never pick into the same working tree at the same time.
+type lock struct {
+	path string
+}
+
+// acquire creates the lock file with the pid and the name of the
+// command. A lock left behind by a process that no longer runs is
+// taken over.
+func acquire(path, command string) (*lock, error) {
+	content := fmt.Sprintf("%d %s\n", os.Getpid(), command)
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			defer file.Close()
+			if _, err := file.WriteString(content); err != nil {
+				return nil, fmt.Errorf("failed to write lock file %q - %w", path, err)
+			}
+			return &lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file %q - %w", path, err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read lock file %q - %w", path, err)
+		}
+		pid, holder := parseLock(string(data))
+		if pid > 0 && alive(pid) {
+			return nil, fmt.Errorf("another rebase process is running in this working tree, pid=%d command=%s, remove %q if it is stale",
+				pid, holder, path)
+		}
+		klog.Infof("taking over a stale lock, pid=%d command=%s is not running - %s", pid, holder, path)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale lock file %q - %w", path, err)
+		}
+	}
+	return nil, fmt.Errorf("failed to acquire lock file %q", path)
+}
+
+func (l *lock) release() {
+	if err := os.Remove(l.path); err != nil {
+		klog.ErrorS(err, "failed to remove lock file", "path", l.path)
+	}
+}
+
+// parseLock returns the pid and the command recorded in a lock file.
+func parseLock(content string) (int, string) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return 0, ""
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, ""
+	}
+	return pid, strings.Join(fields[1:], " ")
+}
+
+func alive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -2,10 +2,13 @@
 
 set -ex
 
-target="v1.24.0"
+# the rebase target, ie. v1.24, v1.24.0 or v1.24.0-rc.1, a release line
+# resolves to its latest upstream tag.
+rebase="${REBASE:-rebase}"
+target=$("${rebase}" resolve-target --target="${1:-v1.24.0}" --print=tag)
 saveto="carry-commits-${target}.log"
-# this is used as the base for carry commits.
-base="v1.23.0"
+# this is used as the base for carry commits, the GA of the previous minor.
+base=$("${rebase}" resolve-target --target="${target}" --print=base)
 
 # this will be the branch we use to generate carry commits
 branch="generate-carry-commits-${target}"
@@ -22,7 +25,10 @@ git checkout -b ${branch} ${target}
 
 git merge -s ours -m "Merge remote-tracking branch 'openshift/master' into ${branch}" openshift/master
 
+# the openshift commit the log is generated from, 'rebase refresh' picks up from here
+echo "# generated: openshift/master=$(git rev-parse openshift/master)" > ${saveto}
+
 git log $(git merge-base openshift/master ${base})..openshift/master --ancestry-path --reverse --no-merges \
 --pretty='tformat:%x09%h%x09%x09%x09%s%x09https://github.com/openshift/kubernetes/commit/%h?w=1' | \
 grep -E $'\t''UPSTREAM: .*'$'\t' | \
-sed -E 's~UPSTREAM: ([0-9]+)(:.*)~UPSTREAM: \1\2\thttps://github.com/kubernetes/kubernetes/pull/\1~' > ${saveto}
+sed -E 's~UPSTREAM: ([0-9]+)(:.*)~UPSTREAM: \1\2\thttps://github.com/kubernetes/kubernetes/pull/\1~' >> ${saveto}